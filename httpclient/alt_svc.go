@@ -0,0 +1,78 @@
+package httpclient
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// defaultAltSvcMaxAge is the freshness of an alternative without ma parameter
+const defaultAltSvcMaxAge = 86400
+
+// altSvcEntry is an alternative service advertised by an Alt-Svc header
+type altSvcEntry struct {
+	protocol  string
+	authority string
+	maxAge    int
+}
+
+// parseAltSvc parses the Alt-Svc headers as defined in RFC 7838, for example
+// `h3=":443"; ma=86400, h2="alt.example.com:443"`, `clear` yields no entry
+func parseAltSvc(headers http.Header) []altSvcEntry {
+	var entries []altSvcEntry
+	for _, header := range headers.Values("Alt-Svc") {
+		for _, alternative := range splitQuoted(header, ',') {
+			params := splitQuoted(alternative, ';')
+			protocol, authority, ok := strings.Cut(strings.TrimSpace(params[0]), "=")
+			if !ok {
+				continue
+			}
+
+			entry := altSvcEntry{
+				protocol:  strings.TrimSpace(protocol),
+				authority: strings.Trim(strings.TrimSpace(authority), `"`),
+				maxAge:    defaultAltSvcMaxAge,
+			}
+			for _, param := range params[1:] {
+				key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if strings.EqualFold(key, "ma") {
+					if ma, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil {
+						entry.maxAge = ma
+					}
+				}
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// splitQuoted splits s on sep, ignoring the separators in quoted strings
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// flattenAltSvc converts the entries for the alt_svc attribute
+func flattenAltSvc(entries []altSvcEntry) []interface{} {
+	flat := make([]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		flat = append(flat, map[string]interface{}{
+			"protocol":  entry.protocol,
+			"authority": entry.authority,
+			"max_age":   entry.maxAge,
+		})
+	}
+	return flat
+}
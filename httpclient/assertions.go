@@ -0,0 +1,134 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+// assertion types accepted by the type attribute of the assertions blocks
+const (
+	assertBodyContains   = "body_contains"
+	assertBodyMatches    = "body_matches"
+	assertHeaderEquals   = "header_equals"
+	assertHeaderExists   = "header_exists"
+	assertJSONPathEquals = "json_path_equals"
+	assertJSONPathExists = "json_path_exists"
+)
+
+// assertionTypes validates the type attribute
+var assertionTypes = []string{assertBodyContains, assertBodyMatches, assertHeaderEquals, assertHeaderExists, assertJSONPathEquals, assertJSONPathExists}
+
+// maxAssertionValueQuote truncates the values quoted in the failures
+const maxAssertionValueQuote = 64
+
+// Assertion is a check of the response beyond its status, the header, path
+// and value used depend on the type
+type Assertion struct {
+	Type   string
+	Header string
+	Path   string
+	Value  string
+}
+
+// validate checks the arguments required by the type
+func (a Assertion) validate() error {
+	switch a.Type {
+	case assertBodyMatches:
+		if _, err := regexp.Compile(a.Value); err != nil {
+			return fmt.Errorf("assertion %s: invalid value: %s", a.Type, err)
+		}
+	case assertHeaderEquals, assertHeaderExists:
+		if len(a.Header) == 0 {
+			return fmt.Errorf("assertion %s: header is required", a.Type)
+		}
+	case assertJSONPathEquals, assertJSONPathExists:
+		if _, err := parseJSONPath(a.Path); err != nil {
+			return fmt.Errorf("assertion %s: %s", a.Type, err)
+		}
+	}
+	return nil
+}
+
+// check returns why the response fails the assertion, empty when it passes
+func (a Assertion) check(rsp *Response) string {
+	switch a.Type {
+	case assertBodyContains:
+		if !strings.Contains(string(rsp.Body), a.Value) {
+			return fmt.Sprintf("body does not contain %q", a.Value)
+		}
+	case assertBodyMatches:
+		if !regexp.MustCompile(a.Value).Match(rsp.Body) {
+			return fmt.Sprintf("body does not match %s", a.Value)
+		}
+	case assertHeaderEquals:
+		if values := rsp.Headers.Values(a.Header); len(values) == 0 {
+			return fmt.Sprintf("header %s is missing, expected %q", a.Header, a.Value)
+		} else if value := strings.Join(values, ", "); value != a.Value {
+			return fmt.Sprintf("header %s is %q, expected %q", a.Header, value, a.Value)
+		}
+	case assertHeaderExists:
+		if len(rsp.Headers.Values(a.Header)) == 0 {
+			return fmt.Sprintf("header %s is missing", a.Header)
+		}
+	case assertJSONPathEquals, assertJSONPathExists:
+		doc, err := decodeJSON(rsp.Body)
+		if err != nil {
+			return fmt.Sprintf("body is not valid json, %s can not be evaluated: %s", a.Path, err)
+		}
+		value, found, _ := jsonPathLookup(doc, a.Path)
+		if !found {
+			return fmt.Sprintf("%s not found in body", a.Path)
+		}
+		if actual := jsonValueString(value); a.Type == assertJSONPathEquals && actual != a.Value {
+			if len(actual) > maxAssertionValueQuote {
+				actual = actual[:maxAssertionValueQuote] + "..."
+			}
+			return fmt.Sprintf("%s is %q, expected %q", a.Path, actual, a.Value)
+		}
+	}
+	return ""
+}
+
+// assertionFailures lists the failed assertions of a response
+type assertionFailures []string
+
+func (f assertionFailures) Error() string {
+	return fmt.Sprintf("%d assertion(s) failed: %s", len(f), strings.Join(f, "; "))
+}
+
+// checkAssertions returns an ASSERTION error listing the failed assertions
+func checkAssertions(rsp *Response, assertions []Assertion) error {
+	var failures assertionFailures
+	for i, assertion := range assertions {
+		if reason := assertion.check(rsp); len(reason) > 0 {
+			failures = append(failures, fmt.Sprintf("assertions[%d] %s: %s", i, assertion.Type, reason))
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return &RequestError{Code: errorCodeAssertion, Err: failures}
+}
+
+// requestErrorDiags converts an error to diagnostics, one per failed
+// assertion for assertion errors
+func requestErrorDiags(err error, severity diag.Severity) diag.Diagnostics {
+	var failures assertionFailures
+	if !errors.As(err, &failures) {
+		return diag.Diagnostics{requestErrorDiag(err, severity)}
+	}
+
+	var diags diag.Diagnostics
+	for _, failure := range failures {
+		diags = append(diags, diag.Diagnostic{
+			Severity: severity,
+			Summary:  fmt.Sprintf("HTTP response assertion failed (%s)", errorCodeAssertion),
+			Detail:   fmt.Sprintf("error_code: %s\n%s", errorCodeAssertion, failure),
+		})
+	}
+	return diags
+}
@@ -0,0 +1,158 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strings"
+)
+
+// authentication types accepted by the auth_type attribute
+const (
+	authBasic    = "basic"
+	authRegistry = "registry"
+)
+
+// authChallenge is a challenge of a WWW-Authenticate header
+type authChallenge struct {
+	scheme string
+	params map[string]string
+}
+
+// parseWWWAuthenticate parses the challenges of a WWW-Authenticate header
+// as defined in RFC 7235, for example `Bearer realm="https://auth",scope="x"`
+func parseWWWAuthenticate(header string) []authChallenge {
+	var challenges []authChallenge
+	s := strings.TrimSpace(header)
+
+	for len(s) > 0 {
+		// auth scheme
+		end := strings.IndexAny(s, " ,")
+		if end == -1 {
+			end = len(s)
+		}
+		challenge := authChallenge{scheme: s[:end], params: make(map[string]string)}
+		s = strings.TrimLeft(s[end:], " ")
+
+		// auth params until the next scheme
+		for len(s) > 0 {
+			s = strings.TrimLeft(s, " ,")
+			eq := strings.Index(s, "=")
+			sep := strings.IndexAny(s, " ,")
+			if eq == -1 || (sep != -1 && sep < eq) {
+				break
+			}
+
+			key := strings.ToLower(strings.TrimSpace(s[:eq]))
+			s = strings.TrimLeft(s[eq+1:], " ")
+
+			var value string
+			if strings.HasPrefix(s, `"`) {
+				var sb strings.Builder
+				i := 1
+				for ; i < len(s) && s[i] != '"'; i++ {
+					if s[i] == '\\' && i+1 < len(s) {
+						i++
+					}
+					sb.WriteByte(s[i])
+				}
+				value = sb.String()
+				s = s[min(i+1, len(s)):]
+			} else {
+				end := strings.Index(s, ",")
+				if end == -1 {
+					end = len(s)
+				}
+				value = strings.TrimSpace(s[:end])
+				s = s[end:]
+			}
+			challenge.params[key] = value
+		}
+
+		if len(challenge.scheme) > 0 {
+			challenges = append(challenges, challenge)
+		}
+	}
+	return challenges
+}
+
+// fetchRegistryToken follows a Docker registry v2 bearer challenge to get a
+// scoped token from the realm, authenticated with the request credentials
+func fetchRegistryToken(ctx context.Context, config *providerConfig, rc *RequestConfig, header string) (string, error) {
+	var challenge *authChallenge
+	for _, c := range parseWWWAuthenticate(header) {
+		if strings.EqualFold(c.scheme, "Bearer") {
+			challenge = &c
+			break
+		}
+	}
+	if challenge == nil || len(challenge.params["realm"]) == 0 {
+		return "", fmt.Errorf("registry authentication: no bearer challenge in WWW-Authenticate header %q", header)
+	}
+
+	realm, err := neturl.Parse(challenge.params["realm"])
+	if err != nil {
+		return "", fmt.Errorf("registry authentication: invalid realm: %w", err)
+	}
+	query := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		if value, ok := challenge.params[key]; ok {
+			query.Set(key, value)
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	// the token endpoint is called with the same tls settings
+	token_rc := authEndpointConfig(rc, realm.String(), http.MethodGet, nil, nil)
+	rsp, err := executeRequest(ctx, config, token_rc)
+	if err != nil {
+		return "", fmt.Errorf("registry authentication: %w", err)
+	}
+	if rsp.StatusCode != http.StatusOK {
+		return "", newRequestError(errorCodeStatus, "registry authentication: token endpoint returned status %d", rsp.StatusCode)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(rsp.Body, &token); err != nil {
+		return "", newRequestError(errorCodeBodyDecode, "registry authentication: invalid token response: %w", err)
+	}
+	if len(token.Token) > 0 {
+		return token.Token, nil
+	}
+	if len(token.AccessToken) > 0 {
+		return token.AccessToken, nil
+	}
+	return "", newRequestError(errorCodeBodyDecode, "registry authentication: no token in token endpoint response")
+}
+
+// authEndpointConfig returns a request to an authentication endpoint, it
+// keeps the credentials and the tls settings of the request but none of its
+// request specific options
+func authEndpointConfig(rc *RequestConfig, url string, method string, headers map[string]string, body []byte) *RequestConfig {
+	endpoint_rc := *rc
+	endpoint_rc.URL = url
+	endpoint_rc.Method = method
+	endpoint_rc.Headers = headers
+	endpoint_rc.Body = body
+	endpoint_rc.AuthType = authBasic
+	endpoint_rc.AuthRequest = nil
+	endpoint_rc.Keystone = nil
+	endpoint_rc.NTLM = nil
+	endpoint_rc.OAuth2 = nil
+	endpoint_rc.AWSSigV4 = nil
+	endpoint_rc.CredentialCommand = nil
+	endpoint_rc.DateHeaders = nil
+	endpoint_rc.CompressBody = ""
+	endpoint_rc.BodyWriter = nil
+	endpoint_rc.UseSRVLookup = false
+	endpoint_rc.HeadersOnly = false
+	endpoint_rc.PreflightHead = false
+	endpoint_rc.SuccessWhen = ""
+	endpoint_rc.Assertions = nil
+	return &endpoint_rc
+}
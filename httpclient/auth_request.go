@@ -0,0 +1,104 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// AuthRequest is a login request sent before the request, the headers of
+// the request are derived from its response
+type AuthRequest struct {
+	URL     string
+	Method  string
+	Headers map[string]string
+	Body    []byte
+
+	Mappings []AuthHeaderMapping
+}
+
+// AuthHeaderMapping sets a request header from a header or a json value of
+// the login response
+type AuthHeaderMapping struct {
+	Name         string
+	FromHeader   string
+	FromJSONPath string
+	// Prefix is prepended to the value, such as "Bearer "
+	Prefix string
+}
+
+// executeLoginRequest sends the login request with the credentials of the
+// request and returns the mapped headers
+func executeLoginRequest(ctx context.Context, config *providerConfig, rc *RequestConfig) (map[string]string, error) {
+	auth := rc.AuthRequest
+	login_rc := authEndpointConfig(rc, auth.URL, auth.Method, auth.Headers, auth.Body)
+
+	rsp, err := executeRequest(ctx, config, login_rc)
+	if err != nil {
+		return nil, fmt.Errorf("auth_request: %w", err)
+	}
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return nil, newRequestError(errorCodeStatus, "auth_request: login endpoint returned status %d", rsp.StatusCode)
+	}
+
+	headers := make(map[string]string)
+	for _, mapping := range auth.Mappings {
+		value, err := mapping.value(rsp)
+		if err != nil {
+			return nil, err
+		}
+		headers[mapping.Name] = mapping.Prefix + value
+	}
+	return headers, nil
+}
+
+// value extracts the header value from the login response
+func (m AuthHeaderMapping) value(rsp *Response) (string, error) {
+	if (len(m.FromHeader) == 0) == (len(m.FromJSONPath) == 0) {
+		return "", newRequestError(errorCodeConfig, "auth_request: header %s requires exactly one of from_header or from_json_path", m.Name)
+	}
+
+	if len(m.FromHeader) > 0 {
+		value := rsp.Headers.Get(m.FromHeader)
+		if len(value) == 0 {
+			return "", newRequestError(errorCodeBodyDecode, "auth_request: no %s header in login response for header %s", m.FromHeader, m.Name)
+		}
+		return value, nil
+	}
+
+	doc, err := decodeJSON(rsp.Body)
+	if err != nil {
+		return "", newRequestError(errorCodeBodyDecode, "auth_request: login response is not valid json: %s", err)
+	}
+	value, found, err := jsonPathLookup(doc, m.FromJSONPath)
+	if err != nil {
+		return "", newRequestError(errorCodeConfig, "auth_request: header %s: %s", m.Name, err)
+	}
+	if !found {
+		return "", newRequestError(errorCodeBodyDecode, "auth_request: %s not found in login response for header %s", m.FromJSONPath, m.Name)
+	}
+	return jsonValueString(value), nil
+}
+
+// withAuthHeaders returns a copy of the request sending the headers instead
+// of its credentials
+func withAuthHeaders(rc *RequestConfig, auth_headers map[string]string) *RequestConfig {
+	authenticated_rc := *rc
+	authenticated_rc.AuthRequest = nil
+	authenticated_rc.Username = ""
+	authenticated_rc.Password = ""
+
+	authenticated_rc.Headers = make(map[string]string, len(rc.Headers)+len(auth_headers))
+	for name, value := range rc.Headers {
+		authenticated_rc.Headers[name] = value
+	}
+	for name, value := range auth_headers {
+		for existing := range authenticated_rc.Headers {
+			if strings.EqualFold(existing, name) {
+				delete(authenticated_rc.Headers, existing)
+			}
+		}
+		authenticated_rc.Headers[name] = value
+	}
+	return &authenticated_rc
+}
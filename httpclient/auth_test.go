@@ -0,0 +1,63 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestConfig returns a provider configuration with the defaults of
+// providerConfigure
+func newTestConfig() *providerConfig {
+	return &providerConfig{
+		sessionCache: tls.NewLRUClientSessionCache(256),
+		stats:        newRunStats(),
+		tokenCache:   newTokenCache(),
+		sessions:     newSessionJars(),
+	}
+}
+
+func TestOAuth2WithAssertions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token":"secret","expires_in":3600}`))
+		case "/api":
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("X-Api", "v1")
+			w.Write([]byte(`{"status":"ready"}`))
+		}
+	}))
+	defer server.Close()
+
+	// the assertions only apply to the response of the request, not to the
+	// token endpoint one
+	rc := &RequestConfig{
+		URL:    server.URL + "/api",
+		Method: http.MethodGet,
+		OAuth2: &OAuth2{TokenURL: server.URL + "/token", ClientID: "id", ClientSecret: "secret"},
+		Assertions: []Assertion{
+			{Type: assertHeaderEquals, Header: "X-Api", Value: "v1"},
+			{Type: assertJSONPathEquals, Path: "$.status", Value: "ready"},
+		},
+	}
+	rsp, err := ExecuteRequest(context.Background(), newTestConfig(), rc)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if rsp.StatusCode != http.StatusOK {
+		t.Errorf("status is %d, expected 200", rsp.StatusCode)
+	}
+
+	// a failed assertion is reported for the request
+	rc.Assertions = []Assertion{{Type: assertBodyContains, Value: "stopped"}}
+	if _, err := ExecuteRequest(context.Background(), newTestConfig(), rc); errorCode(err) != errorCodeAssertion {
+		t.Errorf("error code is %s, expected %s", errorCode(err), errorCodeAssertion)
+	}
+}
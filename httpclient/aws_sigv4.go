@@ -0,0 +1,232 @@
+package httpclient
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// awsSigV4Algorithm is the signing algorithm of the Authorization header
+const awsSigV4Algorithm = "AWS4-HMAC-SHA256"
+
+// AWSSigV4 are the settings of the AWS Signature Version 4 request signing
+type AWSSigV4 struct {
+	Region  string
+	Service string
+
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	// UseDefaultCredentials reads the credentials from the AWS environment
+	// variables or the shared credentials file when AccessKey is not set
+	UseDefaultCredentials bool
+}
+
+// awsCredentials returns the access key, secret key and session token
+func (s *AWSSigV4) awsCredentials() (string, string, string, error) {
+	if len(s.AccessKey) > 0 {
+		return s.AccessKey, s.SecretKey, s.SessionToken, nil
+	}
+	if !s.UseDefaultCredentials {
+		return "", "", "", newRequestError(errorCodeConfig, "aws_sigv4: access_key and secret_key are required without use_default_credentials")
+	}
+
+	// environment variables take precedence over the shared credentials file
+	if access_key := os.Getenv("AWS_ACCESS_KEY_ID"); len(access_key) > 0 {
+		return access_key, os.Getenv("AWS_SECRET_ACCESS_KEY"), os.Getenv("AWS_SESSION_TOKEN"), nil
+	}
+
+	path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if len(path) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", "", "", newRequestError(errorCodeConfig, "aws_sigv4: %s", err)
+		}
+		path = filepath.Join(home, ".aws", "credentials")
+	}
+	profile := os.Getenv("AWS_PROFILE")
+	if len(profile) == 0 {
+		profile = "default"
+	}
+
+	values, err := readAWSProfile(path, profile)
+	if err != nil {
+		return "", "", "", newRequestError(errorCodeConfig, "aws_sigv4: no credentials in the environment: %s", err)
+	}
+	if len(values["aws_access_key_id"]) == 0 {
+		return "", "", "", newRequestError(errorCodeConfig, "aws_sigv4: no aws_access_key_id in profile %s of %s", profile, path)
+	}
+	return values["aws_access_key_id"], values["aws_secret_access_key"], values["aws_session_token"], nil
+}
+
+// readAWSProfile returns the keys of a profile of an ini formatted shared
+// credentials file
+func readAWSProfile(path string, profile string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var values map[string]string
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case len(line) == 0 || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if section == profile {
+				values = make(map[string]string)
+			}
+		case section == profile:
+			key, value, _ := strings.Cut(line, "=")
+			values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if values == nil {
+		return nil, fmt.Errorf("profile %s not found in %s", profile, path)
+	}
+	return values, nil
+}
+
+// signAWSSigV4 adds the Authorization header of the AWS Signature Version 4
+// to the request, it must be called once the headers and body are final
+func signAWSSigV4(req *http.Request, body []byte, sigv4 *AWSSigV4, now time.Time) error {
+	access_key, secret_key, session_token, err := sigv4.awsCredentials()
+	if err != nil {
+		return err
+	}
+
+	now = now.UTC()
+	amz_date := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payload_sum := sha256.Sum256(body)
+	payload_hash := hex.EncodeToString(payload_sum[:])
+
+	req.Header.Set("X-Amz-Date", amz_date)
+	if len(session_token) > 0 {
+		req.Header.Set("X-Amz-Security-Token", session_token)
+	}
+	if sigv4.Service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payload_hash)
+	}
+
+	// canonical headers, the host and all the headers of the request
+	headers := map[string]string{"host": req.Host}
+	if len(req.Host) == 0 {
+		headers["host"] = req.URL.Host
+	}
+	for name, values := range req.Header {
+		trimmed := make([]string, len(values))
+		for i, value := range values {
+			trimmed[i] = strings.Join(strings.Fields(value), " ")
+		}
+		headers[strings.ToLower(name)] = strings.Join(trimmed, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonical_headers strings.Builder
+	for _, name := range names {
+		canonical_headers.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signed_headers := strings.Join(names, ";")
+
+	canonical_request := strings.Join([]string{
+		req.Method,
+		awsCanonicalPath(req, sigv4.Service),
+		awsCanonicalQuery(req),
+		canonical_headers.String(),
+		signed_headers,
+		payload_hash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, sigv4.Region, sigv4.Service, "aws4_request"}, "/")
+	request_sum := sha256.Sum256([]byte(canonical_request))
+	string_to_sign := strings.Join([]string{awsSigV4Algorithm, amz_date, scope, hex.EncodeToString(request_sum[:])}, "\n")
+
+	key := []byte("AWS4" + secret_key)
+	for _, part := range []string{date, sigv4.Region, sigv4.Service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, string_to_sign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		awsSigV4Algorithm, access_key, scope, signed_headers, signature))
+	return nil
+}
+
+// awsCanonicalPath returns the uri encoded path, encoded twice except for s3
+func awsCanonicalPath(req *http.Request, service string) string {
+	path := req.URL.EscapedPath()
+	if service == "s3" {
+		path = req.URL.Path
+	}
+	if len(path) == 0 {
+		return "/"
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = awsURIEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// awsCanonicalQuery returns the query parameters sorted and uri encoded
+func awsCanonicalQuery(req *http.Request) string {
+	var pairs [][2]string
+	for key, values := range req.URL.Query() {
+		for _, value := range values {
+			pairs = append(pairs, [2]string{awsURIEncode(key), awsURIEncode(value)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+
+	encoded := make([]string, len(pairs))
+	for i, pair := range pairs {
+		encoded[i] = pair[0] + "=" + pair[1]
+	}
+	return strings.Join(encoded, "&")
+}
+
+// awsURIEncode percent encodes all the characters but the unreserved ones
+func awsURIEncode(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
+
+// hmacSHA256 is a step of the signing key derivation
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
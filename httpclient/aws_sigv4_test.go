@@ -0,0 +1,178 @@
+package httpclient
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSignAWSSigV4 uses the requests of the AWS Signature Version 4 test
+// suite, signed with its example credentials
+func TestSignAWSSigV4(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		headers        [][2]string
+		body           string
+		signed_headers string
+		signature      string
+	}{
+		{
+			name:           "get-vanilla",
+			method:         http.MethodGet,
+			url:            "https://example.amazonaws.com/",
+			signed_headers: "host;x-amz-date",
+			signature:      "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name:           "get-vanilla-query-order-key-case",
+			method:         http.MethodGet,
+			url:            "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			signed_headers: "host;x-amz-date",
+			signature:      "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+		{
+			name:           "get-vanilla-query-unreserved",
+			method:         http.MethodGet,
+			url:            "https://example.amazonaws.com/?-._~0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz=-._~0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+			signed_headers: "host;x-amz-date",
+			signature:      "9c3e54bfcdf0b19771a7f523ee5669cdf59bc7cc0884027167c21bb143a40197",
+		},
+		{
+			name:           "get-vanilla-utf8-query",
+			method:         http.MethodGet,
+			url:            "https://example.amazonaws.com/?ሴ=bar",
+			signed_headers: "host;x-amz-date",
+			signature:      "2cdec8eed098649ff3a119c94853b13c643bcf08f8b0a1d91e12c9027818dd04",
+		},
+		{
+			name:           "get-header-key-duplicate",
+			method:         http.MethodGet,
+			url:            "https://example.amazonaws.com/",
+			headers:        [][2]string{{"My-Header1", "value2"}, {"My-Header1", "value2"}, {"My-Header1", "value1"}},
+			signed_headers: "host;my-header1;x-amz-date",
+			signature:      "c9d5ea9f3f72853aea855b47ea873832890dbdd183b4468f858259531a5138ea",
+		},
+		{
+			name:           "get-header-value-trim",
+			method:         http.MethodGet,
+			url:            "https://example.amazonaws.com/",
+			headers:        [][2]string{{"My-Header1", " value1"}, {"My-Header2", ` "a   b   c"`}},
+			signed_headers: "host;my-header1;my-header2;x-amz-date",
+			signature:      "acc3ed3afb60bb290fc8d2dd0098b9911fcaa05412b367055dee359757a9c736",
+		},
+		{
+			name:           "post-vanilla",
+			method:         http.MethodPost,
+			url:            "https://example.amazonaws.com/",
+			signed_headers: "host;x-amz-date",
+			signature:      "5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b",
+		},
+		{
+			name:           "post-vanilla-query",
+			method:         http.MethodPost,
+			url:            "https://example.amazonaws.com/?Param1=value1",
+			signed_headers: "host;x-amz-date",
+			signature:      "28038455d6de14eafc1f9222cf5aa6f1a96197d7deb8263271d420d138af7f11",
+		},
+		{
+			name:           "post-x-www-form-urlencoded",
+			method:         http.MethodPost,
+			url:            "https://example.amazonaws.com/",
+			headers:        [][2]string{{"Content-Type", "application/x-www-form-urlencoded"}},
+			body:           "Param1=value1",
+			signed_headers: "content-type;host;x-amz-date",
+			signature:      "ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a",
+		},
+	}
+
+	sigv4 := &AWSSigV4{
+		Region:    "us-east-1",
+		Service:   "service",
+		AccessKey: "AKIDEXAMPLE",
+		SecretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.url, bytes.NewBufferString(tt.body))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			for _, header := range tt.headers {
+				req.Header.Add(header[0], header[1])
+			}
+			if err := signAWSSigV4(req, []byte(tt.body), sigv4, now); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=" + tt.signed_headers + ", Signature=" + tt.signature
+			if authorization := req.Header.Get("Authorization"); authorization != expected {
+				t.Errorf("Authorization is\n%s\nexpected\n%s", authorization, expected)
+			}
+			if date := req.Header.Get("X-Amz-Date"); date != "20150830T123600Z" {
+				t.Errorf("X-Amz-Date is %s", date)
+			}
+		})
+	}
+}
+
+func TestAWSCanonicalQuery(t *testing.T) {
+	tests := []struct {
+		url   string
+		query string
+	}{
+		{url: "https://example.com/", query: ""},
+		{url: "https://example.com/?b=2&a=1", query: "a=1&b=2"},
+		// duplicate keys are sorted by value
+		{url: "https://example.com/?a=2&a=1", query: "a=1&a=2"},
+		{url: "https://example.com/?key", query: "key="},
+		{url: "https://example.com/?q=a+b&r=a%20b&s=%2F", query: "q=a%20b&r=a%20b&s=%2F"},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+		if query := awsCanonicalQuery(req); query != tt.query {
+			t.Errorf("%s: canonical query is %q, expected %q", tt.url, query, tt.query)
+		}
+	}
+}
+
+func TestAWSCanonicalPath(t *testing.T) {
+	tests := []struct {
+		url     string
+		service string
+		path    string
+	}{
+		{url: "https://example.com", service: "service", path: "/"},
+		{url: "https://example.com/a/b", service: "service", path: "/a/b"},
+		// encoded twice except for s3
+		{url: "https://example.com/a%20b", service: "service", path: "/a%2520b"},
+		{url: "https://example.com/a%20b", service: "s3", path: "/a%20b"},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+		if path := awsCanonicalPath(req, tt.service); path != tt.path {
+			t.Errorf("%s %s: canonical path is %q, expected %q", tt.service, tt.url, path, tt.path)
+		}
+	}
+}
+
+func TestSignAWSSigV4S3PayloadHash(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://bucket.s3.amazonaws.com/key", nil)
+	sigv4 := &AWSSigV4{Region: "us-east-1", Service: "s3", AccessKey: "AKIDEXAMPLE", SecretKey: "secret", SessionToken: "token"}
+	if err := signAWSSigV4(req, nil, sigv4, time.Now()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// the hash of the empty body is sent and signed with the session token
+	if hash := req.Header.Get("X-Amz-Content-Sha256"); hash != "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+		t.Errorf("X-Amz-Content-Sha256 is %s", hash)
+	}
+	if !strings.Contains(req.Header.Get("Authorization"), "SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-amz-security-token,") {
+		t.Errorf("unexpected Authorization %s", req.Header.Get("Authorization"))
+	}
+}
@@ -0,0 +1,78 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+)
+
+// maxCABundleSize bounds the size of the downloaded ca bundle
+const maxCABundleSize = 4 << 20
+
+// fetchCABundle downloads the PEM bundle of the trusted certificate
+// authorities, checks its sha256 when pinned, and returns the system roots
+// completed with the bundle certificates. The bundle adds trust anchors, it
+// must be pinned or downloaded over https.
+func fetchCABundle(ctx context.Context, url string, pinned_sha256 string) (*x509.CertPool, error) {
+	// credentials of the url are not written to the error messages
+	location, _, _, err := splitURLCredentials(url)
+	if err != nil {
+		return nil, errors.New("ca bundle: invalid url")
+	}
+	if !strings.HasPrefix(strings.ToLower(location), "https://") && len(pinned_sha256) == 0 {
+		return nil, fmt.Errorf("ca bundle: %s is not an https url, ca_bundle_sha256 is required", location)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.New("ca bundle: invalid url")
+	}
+	rsp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		var url_err *neturl.Error
+		if errors.As(err, &url_err) {
+			err = url_err.Err
+		}
+		return nil, fmt.Errorf("ca bundle: %s: %w", location, err)
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ca bundle: unexpected status %s from %s", rsp.Status, location)
+	}
+
+	bundle, err := io.ReadAll(io.LimitReader(rsp.Body, maxCABundleSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("ca bundle: %w", err)
+	}
+	if len(bundle) > maxCABundleSize {
+		return nil, fmt.Errorf("ca bundle: %s is larger than %d bytes", location, maxCABundleSize)
+	}
+
+	// the bundle is trusted only when it matches the pinned checksum
+	if len(pinned_sha256) > 0 {
+		sum := sha256.Sum256(bundle)
+		if checksum := hex.EncodeToString(sum[:]); !strings.EqualFold(checksum, pinned_sha256) {
+			return nil, fmt.Errorf("ca bundle: checksum mismatch for %s: expected sha256 %s, got %s", location, pinned_sha256, checksum)
+		}
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(bundle) {
+		return nil, fmt.Errorf("ca bundle: no PEM certificate found in %s", location)
+	}
+	return pool, nil
+}
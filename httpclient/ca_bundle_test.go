@@ -0,0 +1,32 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCABundleRequiresPinOverHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("-----BEGIN CERTIFICATE-----\n"))
+	}))
+	defer server.Close()
+
+	// a bundle downloaded in clear text must be pinned
+	url := strings.Replace(server.URL, "http://", "http://user:secret@", 1)
+	_, err := fetchCABundle(context.Background(), url, "")
+	if err == nil || !strings.Contains(err.Error(), "ca_bundle_sha256 is required") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the credentials of the url are not quoted by the errors
+	_, err = fetchCABundle(context.Background(), url, strings.Repeat("0", 64))
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(err.Error(), "secret") || strings.Contains(err.Error(), "user") {
+		t.Errorf("error quotes the credentials: %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// callbackPlaceholder is replaced by the callback url in the url, headers
+// and body of the request
+const callbackPlaceholder = "{{callback_url}}"
+
+// maxCallbackBodySize limits the body accepted from the callback
+const maxCallbackBodySize = 10 << 20
+
+// CallbackSettings configure the listener waiting for the callback of an
+// asynchronous API
+type CallbackSettings struct {
+	// ListenAddress is the host:port of the listener, port 0 picks one
+	ListenAddress string
+	// PublicURL is the base url the API reaches the listener with, when
+	// it is behind a NAT or a load balancer
+	PublicURL string
+	// CertFile and KeyFile serve the callback over tls
+	CertFile string
+	KeyFile  string
+	Timeout  time.Duration
+}
+
+// callbackResult is the first request received on the callback url
+type callbackResult struct {
+	Method  string
+	Headers http.Header
+	Body    []byte
+}
+
+// callbackListener is a temporary http server accepting a single request on
+// an unguessable path
+type callbackListener struct {
+	url     string
+	timeout time.Duration
+	server  *http.Server
+	result  chan callbackResult
+}
+
+// startCallbackListener listens on the address and serves the callback path
+func startCallbackListener(settings *CallbackSettings) (*callbackListener, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	path := "/callback/" + hex.EncodeToString(token)
+
+	listener, err := net.Listen("tcp", settings.ListenAddress)
+	if err != nil {
+		return nil, newRequestError(errorCodeConfig, "callback listener: %s", err)
+	}
+	scheme := "http"
+	if len(settings.CertFile) > 0 {
+		cert, err := tls.LoadX509KeyPair(settings.CertFile, settings.KeyFile)
+		if err != nil {
+			listener.Close()
+			return nil, newRequestError(errorCodeConfig, "callback listener: %s", err)
+		}
+		listener = tls.NewListener(listener, &tls.Config{Certificates: []tls.Certificate{cert}})
+		scheme = "https"
+	}
+
+	l := &callbackListener{
+		url:     scheme + "://" + listener.Addr().String() + path,
+		timeout: settings.Timeout,
+		result:  make(chan callbackResult, 1),
+	}
+	if len(settings.PublicURL) > 0 {
+		l.url = strings.TrimSuffix(settings.PublicURL, "/") + path
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxCallbackBodySize))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		// only the first callback is kept
+		select {
+		case l.result <- callbackResult{Method: r.Method, Headers: r.Header.Clone(), Body: body}:
+		default:
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	l.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go l.server.Serve(listener)
+
+	return l, nil
+}
+
+// render replaces the callback placeholder of the request
+func (l *callbackListener) render(rc *RequestConfig) {
+	rc.URL = strings.ReplaceAll(rc.URL, callbackPlaceholder, l.url)
+	for name, value := range rc.Headers {
+		rc.Headers[name] = strings.ReplaceAll(value, callbackPlaceholder, l.url)
+	}
+	rc.Body = []byte(strings.ReplaceAll(string(rc.Body), callbackPlaceholder, l.url))
+}
+
+// wait returns the callback, or a timeout error once the timeout expires
+func (l *callbackListener) wait(ctx context.Context) (*callbackResult, error) {
+	select {
+	case result := <-l.result:
+		return &result, nil
+	case <-time.After(l.timeout):
+		return nil, newRequestError(errorCodeTimeout, "no callback received on %s after %s", l.url, l.timeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// close stops the listener, the pending callbacks are dropped
+func (l *callbackListener) close() {
+	l.server.Close()
+}
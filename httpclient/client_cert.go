@@ -0,0 +1,84 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"github.com/spiffe/go-spiffe/v2/workloadapi"
+)
+
+// clientIdentity loads the client certificate from files, they are checked
+// on each handshake and reloaded when modified, so short-lived certificates
+// rotated on disk are picked up without restarting the provider
+type clientIdentity struct {
+	certFile string
+	keyFile  string
+
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	certTime time.Time
+	keyTime  time.Time
+}
+
+func newClientIdentity(certFile, keyFile string) (*clientIdentity, error) {
+	identity := &clientIdentity{certFile: certFile, keyFile: keyFile}
+
+	// fail early on a broken configuration
+	if _, err := identity.certificate(); err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+// certificate returns the current certificate, reloaded when a file changed
+func (c *clientIdentity) certificate() (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cert_info, err := os.Stat(c.certFile)
+	if err != nil {
+		return nil, err
+	}
+	key_info, err := os.Stat(c.keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.cert != nil && cert_info.ModTime().Equal(c.certTime) && key_info.ModTime().Equal(c.keyTime) {
+		return c.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return nil, err
+	}
+	c.cert = &cert
+	c.certTime = cert_info.ModTime()
+	c.keyTime = key_info.ModTime()
+
+	return c.cert, nil
+}
+
+// GetClientCertificate implements the tls.Config callback
+func (c *clientIdentity) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return c.certificate()
+}
+
+// newSVIDSource connects to the spiffe workload api, the X.509 SVIDs are kept
+// up to date in the background for the lifetime of the provider
+func newSVIDSource(socketPath string) (x509svid.Source, error) {
+	var opts []workloadapi.X509SourceOption
+	if len(socketPath) > 0 {
+		opts = append(opts, workloadapi.WithClientOptions(workloadapi.WithAddr(socketPath)))
+	}
+
+	// wait for the first svid but not forever
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	return workloadapi.NewX509Source(ctx, opts...)
+}
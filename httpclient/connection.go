@@ -0,0 +1,321 @@
+package httpclient
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// connectionSchema are the authentication, tls and proxy arguments shared by
+// the httpclient_request data source and resource
+func connectionSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"username": {
+			Type:     schema.TypeString,
+			Optional: true,
+			Default:  "",
+		},
+		"password": {
+			Type:     schema.TypeString,
+			Optional: true,
+			Default:  "",
+		},
+		"credentials": {
+			Type:          schema.TypeString,
+			Optional:      true,
+			Default:       "",
+			ConflictsWith: []string{"username", "password", "bearer_token", "api_key", "credential_command", "auth_request", "oauth2", "keystone", "aws_sigv4"},
+		},
+		"bearer_token": {
+			Type:          schema.TypeString,
+			Optional:      true,
+			Sensitive:     true,
+			Default:       "",
+			ConflictsWith: []string{"username", "password", "credential_command", "auth_request", "oauth2", "keystone", "api_key"},
+		},
+		"api_key": {
+			Type:          schema.TypeString,
+			Optional:      true,
+			Sensitive:     true,
+			Default:       "",
+			ConflictsWith: []string{"username", "password", "credential_command", "auth_request", "oauth2", "keystone"},
+		},
+		"api_key_header": {
+			Type:     schema.TypeString,
+			Optional: true,
+			Default:  "X-API-Key",
+		},
+		"credential_command": {
+			Type:          schema.TypeList,
+			Optional:      true,
+			ConflictsWith: []string{"username", "password"},
+			Elem:          &schema.Schema{Type: schema.TypeString},
+		},
+		"auth_request": {
+			Type:     schema.TypeList,
+			Optional: true,
+			MaxItems: 1,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"url": {
+						Type:     schema.TypeString,
+						Required: true,
+					},
+					"request_method": {
+						Type:     schema.TypeString,
+						Optional: true,
+						Default:  "POST",
+					},
+					"request_headers": {
+						Type:     schema.TypeMap,
+						Optional: true,
+						Elem:     &schema.Schema{Type: schema.TypeString},
+					},
+					"request_body": {
+						Type:      schema.TypeString,
+						Optional:  true,
+						Sensitive: true,
+					},
+					"header": {
+						Type:     schema.TypeList,
+						Required: true,
+						Elem: &schema.Resource{
+							Schema: map[string]*schema.Schema{
+								"name": {
+									Type:     schema.TypeString,
+									Required: true,
+								},
+								"from_header": {
+									Type:     schema.TypeString,
+									Optional: true,
+								},
+								"from_json_path": {
+									Type:     schema.TypeString,
+									Optional: true,
+								},
+								"prefix": {
+									Type:     schema.TypeString,
+									Optional: true,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"oauth2": {
+			Type:     schema.TypeList,
+			Optional: true,
+			MaxItems: 1,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"token_url": {
+						Type:     schema.TypeString,
+						Required: true,
+					},
+					"client_id": {
+						Type:     schema.TypeString,
+						Required: true,
+					},
+					"client_secret": {
+						Type:      schema.TypeString,
+						Required:  true,
+						Sensitive: true,
+					},
+					"scopes": {
+						Type:     schema.TypeList,
+						Optional: true,
+						Elem:     &schema.Schema{Type: schema.TypeString},
+					},
+					"audience": {
+						Type:     schema.TypeString,
+						Optional: true,
+					},
+					"auth_style": {
+						Type:         schema.TypeString,
+						Optional:     true,
+						Default:      oauth2AuthBasic,
+						ValidateFunc: validation.StringInSlice([]string{oauth2AuthBasic, oauth2AuthPost}, false),
+					},
+				},
+			},
+		},
+		"auth_type": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      authBasic,
+			ValidateFunc: validation.StringInSlice([]string{authBasic, authRegistry, authKeystone, authNTLM}, false),
+		},
+		"ntlm": {
+			Type:     schema.TypeList,
+			Optional: true,
+			MaxItems: 1,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"domain": {
+						Type:     schema.TypeString,
+						Optional: true,
+						Default:  "",
+					},
+					"workstation": {
+						Type:     schema.TypeString,
+						Optional: true,
+						Default:  "",
+					},
+				},
+			},
+		},
+		"keystone": {
+			Type:     schema.TypeList,
+			Optional: true,
+			MaxItems: 1,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"auth_url": {
+						Type:     schema.TypeString,
+						Required: true,
+					},
+					"user_domain_name": {
+						Type:     schema.TypeString,
+						Optional: true,
+						Default:  "Default",
+					},
+					"project_id": {
+						Type:     schema.TypeString,
+						Optional: true,
+					},
+					"project_name": {
+						Type:     schema.TypeString,
+						Optional: true,
+					},
+					"project_domain_name": {
+						Type:     schema.TypeString,
+						Optional: true,
+						Default:  "Default",
+					},
+					"application_credential_id": {
+						Type:     schema.TypeString,
+						Optional: true,
+					},
+					"application_credential_secret": {
+						Type:      schema.TypeString,
+						Optional:  true,
+						Sensitive: true,
+					},
+				},
+			},
+		},
+		"aws_sigv4": {
+			Type:          schema.TypeList,
+			Optional:      true,
+			MaxItems:      1,
+			ConflictsWith: []string{"username", "password", "bearer_token", "api_key", "credential_command", "auth_request", "oauth2", "keystone"},
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"region": {
+						Type:     schema.TypeString,
+						Required: true,
+					},
+					"service": {
+						Type:     schema.TypeString,
+						Required: true,
+					},
+					"access_key": {
+						Type:         schema.TypeString,
+						Optional:     true,
+						RequiredWith: []string{"aws_sigv4.0.secret_key"},
+					},
+					"secret_key": {
+						Type:      schema.TypeString,
+						Optional:  true,
+						Sensitive: true,
+					},
+					"session_token": {
+						Type:      schema.TypeString,
+						Optional:  true,
+						Sensitive: true,
+					},
+					"use_default_credentials": {
+						Type:     schema.TypeBool,
+						Optional: true,
+						Default:  false,
+					},
+				},
+			},
+		},
+		"insecure": {
+			Type:     schema.TypeBool,
+			Optional: true,
+			Default:  false,
+		},
+		"skip_tls_verify_hostname": {
+			Type:     schema.TypeBool,
+			Optional: true,
+			Default:  false,
+		},
+		"check_revocation": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      revocationOff,
+			ValidateFunc: validation.StringInSlice([]string{revocationOff, revocationOCSP, revocationCRL}, false),
+		},
+		"tls_renegotiation": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      "never",
+			ValidateFunc: validation.StringInSlice([]string{"never", "once", "freely"}, false),
+		},
+		"proxy_url": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      "",
+			ValidateFunc: validation.IsURLWithScheme(proxySchemes),
+		},
+		"no_proxy": {
+			Type:     schema.TypeList,
+			Optional: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		},
+	}
+}
+
+// connectionFromData sets the authentication, tls and proxy settings of the
+// arguments of connectionSchema
+func connectionFromData(d *schema.ResourceData, rc *RequestConfig) error {
+	// the token and key are the only credentials of the request
+	bearer_token, api_key := d.Get("bearer_token").(string), d.Get("api_key").(string)
+	if auth_type := d.Get("auth_type").(string); (len(bearer_token) > 0 || len(api_key) > 0) && auth_type != authBasic {
+		return newRequestError(errorCodeConfig, "bearer_token and api_key can not be used with the %s auth_type", auth_type)
+	}
+
+	var credential_command []string
+	for _, arg := range d.Get("credential_command").([]interface{}) {
+		credential_command = append(credential_command, arg.(string))
+	}
+
+	// an empty no_proxy list keeps the provider one
+	var no_proxy []string
+	for _, host := range d.Get("no_proxy").([]interface{}) {
+		no_proxy = append(no_proxy, host.(string))
+	}
+
+	rc.Username = d.Get("username").(string)
+	rc.Password = d.Get("password").(string)
+	rc.AuthType = d.Get("auth_type").(string)
+	rc.Credentials = d.Get("credentials").(string)
+	rc.BearerToken = bearer_token
+	rc.APIKey = api_key
+	rc.APIKeyHeader = d.Get("api_key_header").(string)
+	rc.CredentialCommand = credential_command
+	rc.AuthRequest = authRequestFromData(d)
+	rc.Keystone = keystoneFromData(d)
+	rc.NTLM = ntlmFromData(d)
+	rc.OAuth2 = oauth2FromData(d)
+	rc.AWSSigV4 = awsSigV4FromData(d)
+	rc.Insecure = d.Get("insecure").(bool)
+	rc.SkipTLSVerifyHostname = d.Get("skip_tls_verify_hostname").(bool)
+	rc.CheckRevocation = d.Get("check_revocation").(string)
+	rc.TLSRenegotiation = d.Get("tls_renegotiation").(string)
+	rc.ProxyURL = d.Get("proxy_url").(string)
+	rc.NoProxy = no_proxy
+	return nil
+}
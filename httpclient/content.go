@@ -0,0 +1,151 @@
+package httpclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// contentInfo describes the payload of a response
+type contentInfo struct {
+	contentType     string
+	contentEncoding string
+	charset         string
+	isBinary        bool
+}
+
+// detectContent parses the content headers and sniffs the body to guess
+// whether the payload is binary
+func detectContent(headers http.Header, body []byte) contentInfo {
+	info := contentInfo{
+		contentEncoding: headers.Get("Content-Encoding"),
+	}
+
+	media_type, params, err := mime.ParseMediaType(headers.Get("Content-Type"))
+	if err == nil {
+		info.contentType = media_type
+		info.charset = strings.ToLower(params["charset"])
+	}
+
+	// fallback on the content sniffing algorithm when the header is missing
+	if len(info.contentType) == 0 && len(body) > 0 {
+		media_type, params, _ = mime.ParseMediaType(http.DetectContentType(body))
+		info.contentType = media_type
+		if len(info.charset) == 0 {
+			info.charset = strings.ToLower(params["charset"])
+		}
+	}
+
+	info.isBinary = isBinary(body)
+	return info
+}
+
+// isBinary guesses if the body is binary data rather than text
+func isBinary(body []byte) bool {
+	// only look at the beginning of large bodies
+	sample := body
+	truncated := false
+	if len(sample) > 8192 {
+		sample = sample[:8192]
+		truncated = true
+	}
+
+	if bytes.IndexByte(sample, 0) != -1 {
+		return true
+	}
+	if utf8.Valid(sample) {
+		return false
+	}
+
+	// a multi bytes character can be cut at the end of the sample
+	if truncated {
+		for i := 1; i < utf8.UTFMax; i++ {
+			if utf8.Valid(sample[:len(sample)-i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// compressBody compresses the request body with the given Content-Encoding
+func compressBody(body []byte, encoding string) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "zstd":
+		zw, err := zstd.NewWriter(&buf)
+		if err != nil {
+			return nil, err
+		}
+		w = zw
+	default:
+		return nil, fmt.Errorf("unsupported request body compression %q", encoding)
+	}
+
+	if _, err := w.Write(body); err != nil {
+		w.Close()
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// acceptRange is a media range of the Accept header with its quality
+type acceptRange struct {
+	mediaType string
+	quality   float64
+}
+
+// renderAccept renders the media ranges as an Accept header value, the
+// quality is omitted when it is the default of 1
+func renderAccept(ranges []acceptRange) string {
+	parts := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		if r.quality >= 1 {
+			parts = append(parts, r.mediaType)
+		} else {
+			parts = append(parts, r.mediaType+";q="+strconv.FormatFloat(r.quality, 'f', -1, 64))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// acceptMatches reports whether the content type is acceptable, wildcards
+// such as `text/*` or `*/*` are supported and a zero quality excludes a type
+func acceptMatches(ranges []acceptRange, contentType string) bool {
+	if len(contentType) == 0 {
+		return false
+	}
+	content_main, content_sub, _ := strings.Cut(strings.ToLower(contentType), "/")
+
+	for _, r := range ranges {
+		main, sub, _ := strings.Cut(strings.ToLower(r.mediaType), "/")
+		if (main == "*" || main == content_main) && (sub == "*" || sub == content_sub) {
+			return r.quality > 0
+		}
+	}
+	return false
+}
+
+// subresourceIntegrity returns the sha384 integrity metadata of the body, as
+// used by the integrity attribute of script and link elements
+func subresourceIntegrity(body []byte) string {
+	sum := sha512.Sum384(body)
+	return "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,60 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+)
+
+// branches executed by create_if_absent
+const (
+	branchExists  = "exists"
+	branchCreated = "created"
+)
+
+// CreateIfAbsent is the request creating the resource when it is not found
+type CreateIfAbsent struct {
+	// URL defaults to the url of the request
+	URL     string
+	Method  string
+	Headers map[string]string
+	Body    []byte
+}
+
+// executeCreateIfAbsent sends the request and, when it returns 404, the
+// create request instead, the executed branch is returned with the response
+func executeCreateIfAbsent(ctx context.Context, config *providerConfig, rc *RequestConfig, create *CreateIfAbsent) (*Response, string, error) {
+	// a missing resource is not a failure of the lookup, assertions are
+	// only checked on the final response
+	lookup_rc := *rc
+	if len(rc.SuccessWhen) > 0 {
+		lookup_rc.SuccessWhen = "code == 404 || (" + rc.SuccessWhen + ")"
+	}
+	lookup_rc.Assertions = nil
+
+	rsp, err := ExecuteRequest(ctx, config, &lookup_rc)
+	if err != nil {
+		return rsp, branchExists, err
+	}
+	if rsp.StatusCode != http.StatusNotFound {
+		return rsp, branchExists, checkAssertions(rsp, rc.Assertions)
+	}
+
+	create_rc := *rc
+	if len(create.URL) > 0 {
+		create_rc.URL = create.URL
+	}
+	create_rc.Method = create.Method
+	create_rc.Body = create.Body
+	create_rc.HeadersOnly = false
+	create_rc.PreflightHead = false
+	create_rc.Headers = make(map[string]string, len(rc.Headers)+len(create.Headers))
+	for name, value := range rc.Headers {
+		create_rc.Headers[name] = value
+	}
+	for name, value := range create.Headers {
+		create_rc.Headers[name] = value
+	}
+
+	rsp, err = ExecuteRequest(ctx, config, &create_rc)
+	return rsp, branchCreated, err
+}
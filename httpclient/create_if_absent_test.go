@@ -0,0 +1,56 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCreateIfAbsentAssertions(t *testing.T) {
+	created := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			created = true
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"1"}`))
+		case created:
+			w.Write([]byte(`{"id":"1"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	rc := &RequestConfig{
+		URL:         server.URL + "/items/1",
+		Method:      http.MethodGet,
+		SuccessWhen: "code >= 200 && code <= 299",
+		Assertions:  []Assertion{{Type: assertJSONPathEquals, Path: "$.id", Value: "1"}},
+	}
+	create := &CreateIfAbsent{URL: server.URL + "/items", Method: http.MethodPost, Body: []byte(`{"id":"1"}`)}
+
+	// the 404 of the lookup does not fail the assertions
+	rsp, branch, err := executeCreateIfAbsent(context.Background(), newTestConfig(), rc, create)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if branch != branchCreated || rsp.StatusCode != http.StatusCreated {
+		t.Errorf("branch is %s with status %d, expected %s with status 201", branch, rsp.StatusCode, branchCreated)
+	}
+
+	// the assertions are checked on the response of an existing resource
+	rsp, branch, err = executeCreateIfAbsent(context.Background(), newTestConfig(), rc, create)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if branch != branchExists || rsp.StatusCode != http.StatusOK {
+		t.Errorf("branch is %s with status %d, expected %s with status 200", branch, rsp.StatusCode, branchExists)
+	}
+	rc.Assertions = []Assertion{{Type: assertJSONPathEquals, Path: "$.id", Value: "2"}}
+	if _, _, err := executeCreateIfAbsent(context.Background(), newTestConfig(), rc, create); errorCode(err) != errorCodeAssertion {
+		t.Errorf("error code is %s, expected %s", errorCode(err), errorCodeAssertion)
+	}
+}
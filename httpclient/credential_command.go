@@ -0,0 +1,56 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// credentialCommandTimeout bounds the execution of credential_command
+const credentialCommandTimeout = 30 * time.Second
+
+// commandCredentials is the json document printed by credential_command
+type commandCredentials struct {
+	Token    string `json:"token"`
+	Username string `json:"user"`
+	Password string `json:"pass"`
+}
+
+// runCredentialCommand executes the command and decodes the credentials it
+// prints, they are only kept for the duration of the request
+func runCredentialCommand(ctx context.Context, argv []string) (*commandCredentials, error) {
+	stdout, err := runCommand(ctx, "credential_command", argv, nil, credentialCommandTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var credentials commandCredentials
+	if err := json.Unmarshal(stdout, &credentials); err != nil {
+		return nil, newRequestError(errorCodeConfig, "credential_command: %s did not print a json document: %s", argv[0], err)
+	}
+	if len(credentials.Token) == 0 && len(credentials.Username) == 0 {
+		return nil, newRequestError(errorCodeConfig, "credential_command: %s printed neither token nor user", argv[0])
+	}
+	return &credentials, nil
+}
+
+// withCommandCredentials returns a copy of the request authenticated with the
+// credentials, a token is sent as a bearer token
+func withCommandCredentials(rc *RequestConfig, credentials *commandCredentials) *RequestConfig {
+	authenticated_rc := *rc
+	authenticated_rc.CredentialCommand = nil
+
+	if len(credentials.Token) > 0 {
+		authenticated_rc.Headers = make(map[string]string, len(rc.Headers)+1)
+		for name, value := range rc.Headers {
+			authenticated_rc.Headers[name] = value
+		}
+		authenticated_rc.Headers["Authorization"] = fmt.Sprintf("Bearer %s", credentials.Token)
+		return &authenticated_rc
+	}
+
+	authenticated_rc.Username = credentials.Username
+	authenticated_rc.Password = credentials.Password
+	return &authenticated_rc
+}
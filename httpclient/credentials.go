@@ -0,0 +1,64 @@
+package httpclient
+
+import (
+	"fmt"
+)
+
+// credentialsProfile is a named set of credentials of the provider, selected
+// by the credentials attribute of the requests
+type credentialsProfile struct {
+	username     string
+	password     string
+	bearerToken  string
+	apiKey       string
+	apiKeyHeader string
+}
+
+// credentialsProfiles reads the credentials blocks of the provider
+func credentialsProfiles(blocks []interface{}) (map[string]credentialsProfile, error) {
+	profiles := make(map[string]credentialsProfile)
+	for _, raw := range blocks {
+		block := raw.(map[string]interface{})
+		name := block["name"].(string)
+		if _, ok := profiles[name]; ok {
+			return nil, fmt.Errorf("credentials %s are defined more than once", name)
+		}
+
+		profile := credentialsProfile{
+			username:     block["username"].(string),
+			password:     block["password"].(string),
+			bearerToken:  block["bearer_token"].(string),
+			apiKey:       block["api_key"].(string),
+			apiKeyHeader: block["api_key_header"].(string),
+		}
+		methods := 0
+		for _, set := range []bool{len(profile.username) > 0, len(profile.bearerToken) > 0, len(profile.apiKey) > 0} {
+			if set {
+				methods++
+			}
+		}
+		if methods != 1 {
+			return nil, fmt.Errorf("credentials %s must set exactly one of username, bearer_token or api_key", name)
+		}
+		profiles[name] = profile
+	}
+	return profiles, nil
+}
+
+// withCredentialsProfile returns the request with the credentials of the
+// selected profile
+func withCredentialsProfile(config *providerConfig, rc *RequestConfig) (*RequestConfig, error) {
+	profile, ok := config.credentials[rc.Credentials]
+	if !ok {
+		return nil, newRequestError(errorCodeConfig, "credentials %s are not defined in the provider configuration", rc.Credentials)
+	}
+
+	profile_rc := *rc
+	profile_rc.Credentials = ""
+	profile_rc.Username = profile.username
+	profile_rc.Password = profile.password
+	profile_rc.BearerToken = profile.bearerToken
+	profile_rc.APIKey = profile.apiKey
+	profile_rc.APIKeyHeader = profile.apiKeyHeader
+	return &profile_rc, nil
+}
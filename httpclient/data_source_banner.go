@@ -0,0 +1,163 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// bannerIdleTimeout ends the read once the server stops sending
+const bannerIdleTimeout = 250 * time.Millisecond
+
+func dataSourceBanner() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceBannerRead,
+		Schema: map[string]*schema.Schema{
+			"address": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"tls": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"server_name": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "",
+			},
+			"insecure": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"send": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "",
+			},
+			"read_bytes": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      512,
+				ValidateFunc: validation.IntBetween(1, 65536),
+			},
+			"expected_pattern": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				ValidateFunc: validation.StringIsValidRegExp,
+			},
+			"timeout": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "10s",
+				ValidateFunc: validateDuration,
+			},
+			"banner": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"banner_base64": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"remote_addr": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"tls_report": tlsReportSchema(),
+		},
+	}
+}
+
+func dataSourceBannerRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*providerConfig)
+
+	// get vars
+	address := d.Get("address").(string)
+	timeout, _ := time.ParseDuration(d.Get("timeout").(string))
+	read_bytes := d.Get("read_bytes").(int)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// connect with the dns cache and proxy of the provider
+	dial := (&net.Dialer{}).DialContext
+	if config.dnsCache != nil {
+		dial = config.dnsCache.DialContext
+	}
+	conn, err := dialProxy(ctx, config, address, dial)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer conn.Close()
+	remote_addr := conn.RemoteAddr().String()
+
+	// tls with the same settings as the requests
+	var report *TLSReport
+	if d.Get("tls").(bool) {
+		host, _, _ := net.SplitHostPort(address)
+		if server_name := d.Get("server_name").(string); len(server_name) > 0 {
+			host = server_name
+		}
+		cfg := newTLSConfig(&RequestConfig{Insecure: d.Get("insecure").(bool)}, config, &tlsInfo{})
+		cfg.ServerName = host
+		tls_conn := tls.Client(conn, cfg)
+		if err := tls_conn.HandshakeContext(ctx); err != nil {
+			return diag.Errorf("tls handshake with %s: %s", address, err)
+		}
+		state := tls_conn.ConnectionState()
+		report = newTLSReport(&state, host, cfg.RootCAs, time.Now())
+		conn = tls_conn
+	}
+
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	if send := d.Get("send").(string); len(send) > 0 {
+		if _, err := io.WriteString(conn, send); err != nil {
+			return diag.Errorf("send to %s: %s", address, err)
+		}
+	}
+
+	// read until read_bytes, the end of the stream or the server is idle
+	banner := make([]byte, 0, read_bytes)
+	buf := make([]byte, read_bytes)
+	for len(banner) < read_bytes {
+		n, err := conn.Read(buf[:read_bytes-len(banner)])
+		banner = append(banner, buf[:n]...)
+		if err != nil {
+			if errors.Is(err, io.EOF) || (len(banner) > 0 && errors.Is(err, os.ErrDeadlineExceeded)) {
+				break
+			}
+			return diag.Errorf("read from %s: %s", address, err)
+		}
+		if idle := time.Now().Add(bannerIdleTimeout); idle.Before(deadline) {
+			conn.SetReadDeadline(idle)
+		}
+	}
+
+	if pattern := d.Get("expected_pattern").(string); len(pattern) > 0 && !regexp.MustCompile(pattern).Match(banner) {
+		return diag.Errorf("banner of %s does not match %s: %q", address, pattern, banner)
+	}
+
+	// set data resource
+	d.Set("banner", string(banner))
+	d.Set("banner_base64", base64.StdEncoding.EncodeToString(banner))
+	d.Set("remote_addr", remote_addr)
+	d.Set("tls_report", flattenTLSReport(report))
+	d.SetId(address)
+
+	return nil
+}
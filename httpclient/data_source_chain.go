@@ -0,0 +1,76 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceChain() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceChainRead,
+		Schema: map[string]*schema.Schema{
+			"insecure": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"step": workflowStepsSchema(false),
+			"outputs": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"response_code": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"response_body": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceChainRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*providerConfig)
+
+	// the steps are sent on every read, like the other data sources
+	outputs, rsp, err := executeWorkflowSteps(ctx, config, d.Get("step").([]interface{}), d.Get("insecure").(bool))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// set data resource, the last step is the result of the chain
+	d.Set("outputs", outputs)
+	d.Set("response_code", rsp.StatusCode)
+	d.Set("response_body", string(rsp.Body))
+	d.SetId(chainHash(d.Get("step").([]interface{})))
+
+	return nil
+}
+
+// chainHash identifies the chain by the requests of its steps, hashed as
+// configured, before the values of the previous steps are rendered
+func chainHash(steps []interface{}) string {
+	h := sha256.New()
+	for _, raw := range steps {
+		step := raw.(map[string]interface{})
+		rc := &RequestConfig{
+			URL:     step["url"].(string),
+			Method:  step["request_method"].(string),
+			Headers: make(map[string]string),
+			Body:    []byte(step["request_body"].(string)),
+		}
+		for name, value := range step["request_headers"].(map[string]interface{}) {
+			rc.Headers[name] = value.(string)
+		}
+		fmt.Fprintf(h, "%s\n%s\n", step["name"].(string), requestHash(rc))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,50 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestChainId(t *testing.T) {
+	token := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// every login returns a new token, so the url of the last step changes
+		if r.URL.Path == "/login" {
+			token++
+			fmt.Fprintf(w, `{"token":"t%d"}`, token)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	read := func(items_url string) string {
+		d := schema.TestResourceDataRaw(t, dataSourceChain().Schema, map[string]interface{}{
+			"step": []interface{}{
+				map[string]interface{}{
+					"name":    "login",
+					"url":     server.URL + "/login",
+					"extract": map[string]interface{}{"token": "$.token"},
+				},
+				map[string]interface{}{"name": "items", "url": items_url},
+			},
+		})
+		if diags := dataSourceChainRead(context.Background(), d, newTestConfig()); diags.HasError() {
+			t.Fatalf("unexpected errors: %v", diags)
+		}
+		return d.Id()
+	}
+
+	first := read(server.URL + "/items?token={{login.token}}")
+	if second := read(server.URL + "/items?token={{login.token}}"); second != first {
+		t.Errorf("id changed between reads: %q, %q", first, second)
+	}
+	if other := read(server.URL + "/other?token={{login.token}}"); other == first {
+		t.Errorf("id %q did not change with the configuration", other)
+	}
+}
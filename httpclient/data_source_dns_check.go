@@ -0,0 +1,110 @@
+package httpclient
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourceDNSCheck() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceDNSCheckRead,
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"record_type": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "A",
+				ValidateFunc: validation.StringInSlice([]string{"A", "AAAA", "CNAME", "TXT"}, false),
+			},
+			"resolver": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "",
+			},
+			"records": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceDNSCheckRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	config := m.(*providerConfig)
+
+	// get vars, an overridden name is looked up as the host of its stub
+	name := d.Get("name").(string)
+	lookup_name := overrideName(name, config.overrideHosts)
+	record_type := d.Get("record_type").(string)
+	resolver := newResolver(d.Get("resolver").(string))
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// lookup records
+	var records []string
+	var err error
+	switch record_type {
+	case "A", "AAAA":
+		network := "ip4"
+		if record_type == "AAAA" {
+			network = "ip6"
+		}
+		var ips []net.IP
+		ips, err = resolver.LookupIP(ctx, network, lookup_name)
+		for _, ip := range ips {
+			records = append(records, ip.String())
+		}
+	case "CNAME":
+		var cname string
+		cname, err = resolver.LookupCNAME(ctx, lookup_name)
+		if err == nil {
+			records = append(records, cname)
+		}
+	case "TXT":
+		records, err = resolver.LookupTXT(ctx, lookup_name)
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// keep a stable order between reads
+	sort.Strings(records)
+
+	// set data resource
+	d.Set("records", records)
+	d.SetId(strings.Join([]string{record_type, name}, ":"))
+
+	return nil
+}
+
+// newResolver returns a resolver using the given dns server (host:port),
+// or the system resolver when empty
+func newResolver(server string) *net.Resolver {
+	if len(server) == 0 {
+		return net.DefaultResolver
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, server)
+		},
+	}
+}
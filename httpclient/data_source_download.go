@@ -0,0 +1,149 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceDownload() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceDownloadRead,
+		Schema: map[string]*schema.Schema{
+			"url": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"output_path": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"request_headers": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"username": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"password": {
+				Type:      schema.TypeString,
+				Optional:  true,
+				Sensitive: true,
+			},
+			"insecure": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"timeout": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "10m",
+				ValidateFunc: validateDuration,
+			},
+			"max_size": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				ValidateFunc: validateSize,
+			},
+			"expected_sha256": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "",
+			},
+			"response_code": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"size": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"sha256": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"md5": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceDownloadRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*providerConfig)
+
+	// get vars
+	output_path := d.Get("output_path").(string)
+	timeout, _ := time.ParseDuration(d.Get("timeout").(string))
+	max_size, _ := parseSize(d.Get("max_size").(string))
+	headers := make(map[string]string)
+	for name, value := range d.Get("request_headers").(map[string]interface{}) {
+		headers[name] = value.(string)
+	}
+
+	// the file is written next to the destination, then renamed once complete
+	tmp, err := os.CreateTemp(filepath.Dir(output_path), filepath.Base(output_path)+".*.tmp")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	sha256_hash := sha256.New()
+	md5_hash := md5.New()
+	rc := &RequestConfig{
+		URL:         d.Get("url").(string),
+		Method:      "GET",
+		Headers:     headers,
+		Username:    d.Get("username").(string),
+		Password:    d.Get("password").(string),
+		Insecure:    d.Get("insecure").(bool),
+		Timeout:     timeout,
+		MaxBodySize: max_size,
+		BodyWriter:  io.MultiWriter(tmp, sha256_hash, md5_hash),
+		SuccessWhen: defaultHealthyWhen,
+	}
+
+	rsp, err := ExecuteRequest(ctx, config, rc)
+	if err != nil {
+		return append(diag.Diagnostics{}, requestErrorDiag(err, diag.Error))
+	}
+
+	checksum := hex.EncodeToString(sha256_hash.Sum(nil))
+	if expected := d.Get("expected_sha256").(string); len(expected) > 0 && !strings.EqualFold(expected, checksum) {
+		return diag.Errorf("checksum mismatch for %s: expected sha256 %s, got %s", rsp.URL, expected, checksum)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := os.Rename(tmp.Name(), output_path); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// set data resource
+	d.Set("response_code", rsp.StatusCode)
+	d.Set("size", int(rsp.BodySize))
+	d.Set("sha256", checksum)
+	d.Set("md5", hex.EncodeToString(md5_hash.Sum(nil)))
+	d.SetId(output_path)
+
+	return nil
+}
@@ -0,0 +1,207 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourceGraphQL() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceGraphQLRead,
+		Schema: map[string]*schema.Schema{
+			"endpoint": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"query": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"variables": {
+				Type:          schema.TypeMap,
+				Optional:      true,
+				Elem:          &schema.Schema{Type: schema.TypeString},
+				ConflictsWith: []string{"variables_json"},
+			},
+			"variables_json": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Default:       "",
+				ValidateFunc:  validation.StringIsJSON,
+				ConflictsWith: []string{"variables"},
+			},
+			"operation_name": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "",
+			},
+			"request_headers": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"credentials": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "",
+			},
+			"insecure": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"timeout": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "10s",
+				ValidateFunc: validateDuration,
+			},
+			"fail_on_errors": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"response_code": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"data": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"errors": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"error_messages": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+// graphQLResponse is the envelope of a graphql response, data and errors are
+// kept encoded
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors json.RawMessage `json:"errors"`
+}
+
+func dataSourceGraphQLRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*providerConfig)
+
+	// get vars
+	timeout, _ := time.ParseDuration(d.Get("timeout").(string))
+	headers := make(map[string]string)
+	for name, value := range d.Get("request_headers").(map[string]interface{}) {
+		headers[name] = value.(string)
+	}
+
+	// the json envelope of the operation
+	body, err := graphQLBody(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if len(headerValue(headers, "Content-Type")) == 0 {
+		headers["Content-Type"] = "application/json"
+	}
+	if len(headerValue(headers, "Accept")) == 0 {
+		headers["Accept"] = "application/graphql-response+json, application/json"
+	}
+
+	rc := &RequestConfig{
+		URL:         d.Get("endpoint").(string),
+		Method:      http.MethodPost,
+		Headers:     headers,
+		Body:        body,
+		Credentials: d.Get("credentials").(string),
+		Insecure:    d.Get("insecure").(bool),
+		Timeout:     timeout,
+	}
+	rsp, err := ExecuteRequest(ctx, config, rc)
+	if err != nil {
+		return append(diag.Diagnostics{}, requestErrorDiag(err, diag.Error))
+	}
+
+	// servers answer errors with a 4xx status, the body describes them
+	var envelope graphQLResponse
+	if err := json.Unmarshal(rsp.Body, &envelope); err != nil {
+		return append(diag.Diagnostics{}, requestErrorDiag(newRequestError(errorCodeBodyDecode,
+			"response with status %d is not a graphql response: %s", rsp.StatusCode, err), diag.Error))
+	}
+	messages, err := graphQLErrorMessages(envelope.Errors)
+	if err != nil {
+		return append(diag.Diagnostics{}, requestErrorDiag(newRequestError(errorCodeBodyDecode, "invalid graphql errors: %s", err), diag.Error))
+	}
+	if len(messages) > 0 && d.Get("fail_on_errors").(bool) {
+		return append(diag.Diagnostics{}, requestErrorDiag(newRequestError(errorCodeStatus,
+			"graphql errors: %s", strings.Join(messages, "; ")), diag.Error))
+	}
+
+	// set data resource
+	d.Set("response_code", rsp.StatusCode)
+	d.Set("data", graphQLValue(envelope.Data))
+	d.Set("errors", graphQLValue(envelope.Errors))
+	d.Set("error_messages", messages)
+	d.SetId(requestHash(rc))
+
+	return nil
+}
+
+// graphQLBody builds the request of the operation, variables of the map are
+// strings while variables_json keeps the json types
+func graphQLBody(d *schema.ResourceData) ([]byte, error) {
+	request := map[string]interface{}{
+		"query": d.Get("query").(string),
+	}
+	if operation_name := d.Get("operation_name").(string); len(operation_name) > 0 {
+		request["operationName"] = operation_name
+	}
+	if variables_json := d.Get("variables_json").(string); len(variables_json) > 0 {
+		request["variables"] = json.RawMessage(variables_json)
+	} else if variables := d.Get("variables").(map[string]interface{}); len(variables) > 0 {
+		request["variables"] = variables
+	}
+	return json.Marshal(request)
+}
+
+// graphQLErrorMessages returns the message of each error
+func graphQLErrorMessages(raw json.RawMessage) ([]string, error) {
+	messages := []string{}
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return messages, nil
+	}
+
+	var entries []struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		messages = append(messages, e.Message)
+	}
+	return messages, nil
+}
+
+// graphQLValue is the json of a member of the response, empty when absent
+func graphQLValue(raw json.RawMessage) string {
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return ""
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err != nil {
+		return string(raw)
+	}
+	return compact.String()
+}
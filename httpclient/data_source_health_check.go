@@ -0,0 +1,160 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourceHealthCheck() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceHealthCheckRead,
+		Schema: map[string]*schema.Schema{
+			"url": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"request_method": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "GET",
+			},
+			"request_headers": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"insecure": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"wait_for_status": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeInt,
+					ValidateFunc: validation.IntBetween(100, 599),
+				},
+			},
+			"wait_for_body": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				ValidateFunc: validation.StringIsValidRegExp,
+			},
+			"poll_interval": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "5s",
+				ValidateFunc: validateDuration,
+			},
+			"max_wait": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "5m",
+				ValidateFunc: validateDuration,
+			},
+			"timeout": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "10s",
+				ValidateFunc: validateDuration,
+			},
+			"attempts": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"elapsed_ms": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"response_code": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"response_body": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceHealthCheckRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*providerConfig)
+
+	// get vars, durations and pattern are checked by the schema validation
+	interval, _ := time.ParseDuration(d.Get("poll_interval").(string))
+	max_wait, _ := time.ParseDuration(d.Get("max_wait").(string))
+	timeout, _ := time.ParseDuration(d.Get("timeout").(string))
+	var wait_for_body *regexp.Regexp
+	if pattern := d.Get("wait_for_body").(string); len(pattern) > 0 {
+		wait_for_body = regexp.MustCompile(pattern)
+	}
+	var wait_for_status []int
+	for _, code := range d.Get("wait_for_status").([]interface{}) {
+		wait_for_status = append(wait_for_status, code.(int))
+	}
+	headers := make(map[string]string)
+	for name, value := range d.Get("request_headers").(map[string]interface{}) {
+		headers[name] = value.(string)
+	}
+
+	rc := &RequestConfig{
+		URL:      d.Get("url").(string),
+		Method:   d.Get("request_method").(string),
+		Headers:  headers,
+		Insecure: d.Get("insecure").(bool),
+		Timeout:  timeout,
+	}
+
+	// poll until ready or the deadline expires, failed requests included
+	started_at := time.Now()
+	opts := pollOptions{interval: interval, deadline: started_at.Add(max_wait), retryErrors: true}
+	rsp, attempts, err := pollUntil(ctx, config, rc, opts, func(rsp *Response) (string, error) {
+		return healthCheckReady(rsp, wait_for_status, wait_for_body), nil
+	})
+	var exhausted *pollExhausted
+	if errors.As(err, &exhausted) {
+		url, _, _, _ := splitURLCredentials(rc.URL)
+		return diag.Errorf("%s not ready after %d attempts in %s: %s", url, attempts, time.Since(started_at).Round(time.Second), exhausted.reason)
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// set data resource
+	d.Set("attempts", attempts)
+	d.Set("elapsed_ms", int(time.Since(started_at).Milliseconds()))
+	d.Set("response_code", rsp.StatusCode)
+	d.Set("response_body", string(rsp.Body))
+	d.SetId(rsp.URL)
+
+	return nil
+}
+
+// healthCheckReady checks the response against the expected status codes,
+// any 2xx when none, and body pattern, it returns why the response is not
+// ready, empty when it is
+func healthCheckReady(rsp *Response, wait_for_status []int, wait_for_body *regexp.Regexp) string {
+	status_ok := len(wait_for_status) == 0 && rsp.StatusCode >= 200 && rsp.StatusCode <= 299
+	for _, code := range wait_for_status {
+		if rsp.StatusCode == code {
+			status_ok = true
+		}
+	}
+	if !status_ok {
+		return fmt.Sprintf("unexpected status %d", rsp.StatusCode)
+	}
+	if wait_for_body != nil && !wait_for_body.Match(rsp.Body) {
+		return fmt.Sprintf("response body does not match %s", wait_for_body)
+	}
+	return ""
+}
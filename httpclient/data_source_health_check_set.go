@@ -0,0 +1,249 @@
+package httpclient
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// defaultHealthyWhen applies when the health check set does not set success_when
+const defaultHealthyWhen = "code >= 200 && code < 300"
+
+func dataSourceHealthCheckSet() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceHealthCheckSetRead,
+		Schema: map[string]*schema.Schema{
+			"targets": {
+				Type:     schema.TypeMap,
+				Required: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"request_method": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "GET",
+			},
+			"request_headers": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"insecure": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"timeout": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "10s",
+				ValidateFunc: validateDuration,
+			},
+			"success_when": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				ValidateFunc: validateExpression,
+			},
+			"max_concurrency": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      10,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"fail_on_unhealthy": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"results": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"url": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"healthy": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"response_code": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"latency_ms": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"error_code": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"error_message": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"healthy": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"healthy_count": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"unhealthy_count": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"worst_latency_ms": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"worst_latency_target": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+// healthCheckResult is the outcome of probing one target
+type healthCheckResult struct {
+	name         string
+	url          string
+	healthy      bool
+	responseCode int
+	latency      time.Duration
+	errorCode    string
+	errorMessage string
+}
+
+func dataSourceHealthCheckSetRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*providerConfig)
+
+	// get vars
+	timeout, _ := time.ParseDuration(d.Get("timeout").(string))
+	success_when := d.Get("success_when").(string)
+	if len(success_when) == 0 {
+		success_when = defaultHealthyWhen
+	}
+	headers := make(map[string]string)
+	for name, value := range d.Get("request_headers").(map[string]interface{}) {
+		headers[name] = value.(string)
+	}
+
+	var names []string
+	targets := d.Get("targets").(map[string]interface{})
+	for name := range targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// probe targets concurrently, bounded by max_concurrency
+	method := d.Get("request_method").(string)
+	insecure := d.Get("insecure").(bool)
+	results := make([]healthCheckResult, len(names))
+	runConcurrently(len(names), d.Get("max_concurrency").(int), func(i int) {
+		rc := &RequestConfig{
+			URL:         targets[names[i]].(string),
+			Method:      method,
+			Headers:     headers,
+			Insecure:    insecure,
+			Timeout:     timeout,
+			HeadersOnly: method == "HEAD",
+			SuccessWhen: success_when,
+		}
+		results[i] = probeTarget(ctx, config, names[i], rc)
+	})
+
+	// aggregate results
+	var unhealthy []string
+	var worst healthCheckResult
+	flat_results := make([]interface{}, 0, len(results))
+	for _, result := range results {
+		if !result.healthy {
+			unhealthy = append(unhealthy, result.name)
+		}
+		if result.latency > worst.latency || len(worst.name) == 0 {
+			worst = result
+		}
+		flat_results = append(flat_results, map[string]interface{}{
+			"name":          result.name,
+			"url":           result.url,
+			"healthy":       result.healthy,
+			"response_code": result.responseCode,
+			"latency_ms":    int(result.latency.Milliseconds()),
+			"error_code":    result.errorCode,
+			"error_message": result.errorMessage,
+		})
+	}
+
+	if d.Get("fail_on_unhealthy").(bool) && len(unhealthy) > 0 {
+		return diag.Errorf("%d of %d targets are unhealthy: %s", len(unhealthy), len(results), strings.Join(unhealthy, ", "))
+	}
+
+	// set data resource
+	d.Set("results", flat_results)
+	d.Set("healthy", len(unhealthy) == 0)
+	d.Set("healthy_count", len(results)-len(unhealthy))
+	d.Set("unhealthy_count", len(unhealthy))
+	d.Set("worst_latency_ms", int(worst.latency.Milliseconds()))
+	d.Set("worst_latency_target", worst.name)
+	d.SetId(strings.Join(names, ","))
+
+	return nil
+}
+
+// probeTarget sends the request of one target, errors mark the target unhealthy
+func probeTarget(ctx context.Context, config *providerConfig, name string, rc *RequestConfig) healthCheckResult {
+	result := healthCheckResult{name: name}
+	result.url, _, _, _ = splitURLCredentials(rc.URL)
+	started_at := time.Now()
+
+	rsp, err := ExecuteRequest(ctx, config, rc)
+	result.latency = time.Since(started_at)
+	if rsp != nil {
+		result.responseCode = rsp.StatusCode
+		result.latency = rsp.Duration
+	}
+	if err != nil {
+		result.errorCode = errorCode(err)
+		result.errorMessage = err.Error()
+		return result
+	}
+
+	result.healthy = true
+	return result
+}
+
+// runConcurrently calls fn for each index from 0 to n, with at most max calls
+// in flight, and waits for all of them
+func runConcurrently(n int, max int, fn func(i int)) {
+	slots := make(chan struct{}, max)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
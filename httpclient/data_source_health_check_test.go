@@ -0,0 +1,50 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestHealthCheckPolling(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the service starts on the third attempt
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ready"))
+	}))
+	defer server.Close()
+
+	d := schema.TestResourceDataRaw(t, dataSourceHealthCheck().Schema, map[string]interface{}{
+		"url":           server.URL,
+		"wait_for_body": "^ready$",
+		"poll_interval": "10ms",
+		"max_wait":      "5s",
+	})
+	if diags := dataSourceHealthCheckRead(context.Background(), d, newTestConfig()); diags.HasError() {
+		t.Fatalf("unexpected errors: %v", diags)
+	}
+	if attempts := d.Get("attempts").(int); attempts != 3 {
+		t.Errorf("attempts is %d, expected 3", attempts)
+	}
+
+	// the reason of the last attempt is reported at the deadline
+	d = schema.TestResourceDataRaw(t, dataSourceHealthCheck().Schema, map[string]interface{}{
+		"url":             server.URL,
+		"wait_for_status": []interface{}{http.StatusNoContent},
+		"poll_interval":   "10ms",
+		"max_wait":        "50ms",
+	})
+	diags := dataSourceHealthCheckRead(context.Background(), d, newTestConfig())
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "unexpected status 200") {
+		t.Errorf("unexpected diagnostics: %v", diags)
+	}
+}
@@ -0,0 +1,251 @@
+package httpclient
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// maxMatrixCombinations bounds the number of requests of a matrix
+const maxMatrixCombinations = 256
+
+func dataSourceMatrix() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceMatrixRead,
+		Schema: map[string]*schema.Schema{
+			"url_template": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"matrix": {
+				Type:     schema.TypeList,
+				Required: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringMatch(templateName, "must only contain letters, digits, - and _"),
+						},
+						"values": {
+							Type:     schema.TypeList,
+							Required: true,
+							MinItems: 1,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+			"request_method": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "GET",
+			},
+			"request_headers": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"request_body": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "",
+			},
+			"insecure": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"timeout": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "10s",
+				ValidateFunc: validateDuration,
+			},
+			"success_when": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				ValidateFunc: validateExpression,
+			},
+			"max_concurrency": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      10,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"results": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"key": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"values": {
+							Type:     schema.TypeMap,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"url": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"response_code": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"response_body": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"error_code": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"error_message": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"response_codes": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"all_succeeded": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+		},
+	}
+}
+
+// matrixCombination is one element of the cross product of the matrix
+type matrixCombination struct {
+	key    string
+	values map[string]string
+}
+
+func dataSourceMatrixRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*providerConfig)
+
+	combinations, err := expandMatrix(d.Get("matrix").([]interface{}))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// render a request per combination, before sending any
+	timeout, _ := time.ParseDuration(d.Get("timeout").(string))
+	requests := make([]*RequestConfig, len(combinations))
+	for i, combination := range combinations {
+		rc, err := workflowStepRequest(map[string]interface{}{
+			"url":             d.Get("url_template"),
+			"request_method":  d.Get("request_method"),
+			"request_headers": d.Get("request_headers"),
+			"request_body":    d.Get("request_body"),
+			"success_when":    d.Get("success_when"),
+		}, combination.values)
+		if err != nil {
+			return diag.Errorf("%s: %s", combination.key, err)
+		}
+		rc.Insecure = d.Get("insecure").(bool)
+		rc.Timeout = timeout
+		requests[i] = rc
+	}
+
+	responses := make([]*Response, len(requests))
+	errs := make([]error, len(requests))
+	runConcurrently(len(requests), d.Get("max_concurrency").(int), func(i int) {
+		responses[i], errs[i] = ExecuteRequest(ctx, config, requests[i])
+	})
+
+	// collect results
+	all_succeeded := true
+	response_codes := make(map[string]string)
+	results := make([]interface{}, 0, len(combinations))
+	for i, combination := range combinations {
+		result := map[string]interface{}{
+			"key":    combination.key,
+			"values": combination.values,
+		}
+		result["url"], _, _, _ = splitURLCredentials(requests[i].URL)
+		if rsp := responses[i]; rsp != nil {
+			result["response_code"] = rsp.StatusCode
+			result["response_body"] = string(rsp.Body)
+			response_codes[combination.key] = strconv.Itoa(rsp.StatusCode)
+		}
+		if errs[i] != nil {
+			all_succeeded = false
+			result["error_code"] = errorCode(errs[i])
+			result["error_message"] = errs[i].Error()
+		}
+		results = append(results, result)
+	}
+
+	// set data resource
+	d.Set("results", results)
+	d.Set("response_codes", response_codes)
+	d.Set("all_succeeded", all_succeeded)
+	d.SetId(d.Get("url_template").(string))
+
+	return nil
+}
+
+// expandMatrix returns the cross product of the matrix values, keyed by the
+// name=value pairs sorted by name such as `env=prod,region=eu`
+func expandMatrix(blocks []interface{}) ([]matrixCombination, error) {
+	dimensions := make(map[string][]string)
+	var names []string
+	total := 1
+	for _, raw := range blocks {
+		block := raw.(map[string]interface{})
+		name := block["name"].(string)
+		if _, ok := dimensions[name]; ok {
+			return nil, newRequestError(errorCodeConfig, "matrix %s is defined more than once", name)
+		}
+		for _, value := range block["values"].([]interface{}) {
+			dimensions[name] = append(dimensions[name], value.(string))
+		}
+		names = append(names, name)
+		total *= len(dimensions[name])
+		if total > maxMatrixCombinations {
+			return nil, newRequestError(errorCodeConfig, "the matrix expands to more than %d requests", maxMatrixCombinations)
+		}
+	}
+	sort.Strings(names)
+
+	combinations := []matrixCombination{{values: map[string]string{}}}
+	for _, name := range names {
+		var expanded []matrixCombination
+		for _, combination := range combinations {
+			for _, value := range dimensions[name] {
+				values := make(map[string]string, len(combination.values)+1)
+				for k, v := range combination.values {
+					values[k] = v
+				}
+				values[name] = value
+				expanded = append(expanded, matrixCombination{values: values})
+			}
+		}
+		combinations = expanded
+	}
+
+	for i := range combinations {
+		pairs := make([]string, 0, len(names))
+		for _, name := range names {
+			pairs = append(pairs, name+"="+combinations[i].values[name])
+		}
+		combinations[i].key = strings.Join(pairs, ",")
+	}
+	return combinations, nil
+}
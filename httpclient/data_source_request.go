@@ -1,54 +1,605 @@
 package httpclient
 
 import (
-	"bytes"
 	"context"
-	"crypto/tls"
-	"io/ioutil"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
 	"net/http"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func dataSourceRequest() *schema.Resource {
-	return &schema.Resource{
+	resource := &schema.Resource{
 		ReadContext: dataSourceRequestRead,
 		Schema: map[string]*schema.Schema{
 			"url": {
 				Type:     schema.TypeString,
 				Required: true,
 			},
-			"username": {
+			"session": {
 				Type:     schema.TypeString,
 				Optional: true,
 				Default:  "",
 			},
-			"password": {
+			"request_headers": {
+				Type:     schema.TypeMap,
+				Optional: true,
+			},
+			"accept": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"type": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringMatch(mediaRangePattern, "must be a media range such as application/json or text/*"),
+						},
+						"quality": {
+							Type:         schema.TypeFloat,
+							Optional:     true,
+							Default:      1.0,
+							ValidateFunc: validation.FloatBetween(0, 1),
+						},
+					},
+				},
+			},
+			"response_transformer": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateTransformerName,
+						},
+						"params": {
+							Type:      schema.TypeMap,
+							Optional:  true,
+							Sensitive: true,
+							Elem:      &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+			"etag": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"last_modified": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"not_modified": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"api_version_header": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				RequiredWith: []string{"api_version"},
+			},
+			"api_version": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				RequiredWith: []string{"api_version_header"},
+			},
+			"request_method": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "GET",
+			},
+			"request_body": {
+				Type:      schema.TypeString,
+				Optional:  true,
+				Sensitive: true,
+				Default:   nil,
+			},
+			"soap_envelope": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				ValidateFunc: validation.StringInSlice([]string{"", soapVersion11, soapVersion12}, false),
+			},
+			"soap_action": {
 				Type:     schema.TypeString,
 				Optional: true,
 				Default:  "",
 			},
-			"insecure": {
+			"request_body_form": {
+				Type:          schema.TypeMap,
+				Optional:      true,
+				Sensitive:     true,
+				ConflictsWith: []string{"request_body", "json_patch", "request_body_multipart"},
+				Elem:          &schema.Schema{Type: schema.TypeString},
+			},
+			"request_body_multipart": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				ConflictsWith: []string{"request_body", "json_patch"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"content": {
+							Type:      schema.TypeString,
+							Optional:  true,
+							Sensitive: true,
+						},
+						"file": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"filename": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"content_type": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+					},
+				},
+			},
+			"json_patch": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				ConflictsWith: []string{"request_body"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"op": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringInSlice(jsonPatchOperations, false),
+						},
+						"path": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringMatch(jsonPointerPattern, "must be a JSON pointer such as /spec/replicas"),
+						},
+						"value": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validation.StringIsJSON,
+						},
+						"from": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validation.StringMatch(jsonPointerPattern, "must be a JSON pointer such as /spec/replicas"),
+						},
+					},
+				},
+			},
+			"date_header": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  "Date",
+						},
+						"format": {
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  "http",
+						},
+						"timezone": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "UTC",
+							ValidateFunc: validateTimezone,
+						},
+					},
+				},
+			},
+			"compress_request_body": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				ValidateFunc: validation.StringInSlice([]string{"", "gzip", "zstd"}, false),
+			},
+			"request_body_preview_length": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"create_if_absent": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"url": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"request_method": {
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  "POST",
+						},
+						"request_headers": {
+							Type:     schema.TypeMap,
+							Optional: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"request_body": {
+							Type:      schema.TypeString,
+							Optional:  true,
+							Sensitive: true,
+						},
+					},
+				},
+			},
+			"executed_branch": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"request_body_preview": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"request_body_sha256": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"timeout": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "10s",
+				ValidateFunc: validateDuration,
+			},
+			"max_response_body_size": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				ValidateFunc: validateSize,
+			},
+			"retry_attempts": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntBetween(0, 20),
+			},
+			"retry_min_delay_ms": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      500,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"retry_max_delay_ms": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      10000,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"retry_on_status_codes": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeInt,
+					ValidateFunc: validation.IntBetween(100, 599),
+				},
+			},
+			"retry_non_idempotent": {
 				Type:     schema.TypeBool,
 				Optional: true,
 				Default:  false,
 			},
-			"request_headers": {
+			"retry_until_header": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"value": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"pattern": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validation.StringIsValidRegExp,
+						},
+					},
+				},
+			},
+			"use_srv_lookup": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"assert_remote_ip_in_cidrs": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.IsCIDR,
+				},
+			},
+			"headers_only": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"preflight_head": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"preflight_content_type": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "",
+			},
+			"preflight_max_content_length": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"success_when": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				ValidateFunc: validateExpression,
+			},
+			"assertions": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"type": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringInSlice(assertionTypes, false),
+						},
+						"header": {
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  "",
+						},
+						"path": {
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  "",
+						},
+						"value": {
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  "",
+						},
+					},
+				},
+			},
+			"callback": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"listen_address": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"public_url": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "",
+							ValidateFunc: validation.IsURLWithHTTPorHTTPS,
+						},
+						"tls_cert_file": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "",
+							RequiredWith: []string{"callback.0.tls_key_file"},
+						},
+						"tls_key_file": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "",
+							RequiredWith: []string{"callback.0.tls_cert_file"},
+						},
+						"timeout": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "5m",
+							ValidateFunc: validateDuration,
+						},
+					},
+				},
+			},
+			"callback_body": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"callback_headers": {
 				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"har_file": {
+				Type:     schema.TypeString,
 				Optional: true,
+				Default:  "",
 			},
-			"request_method": {
+			"scrub_patterns": {
+				Type:         schema.TypeMap,
+				Optional:     true,
+				Elem:         &schema.Schema{Type: schema.TypeString},
+				ValidateFunc: validateScrubPatterns,
+			},
+			"body_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				ValidateFunc: validation.StringIsValidRegExp,
+			},
+			"soap_fault": {
 				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"captures": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"response_extract": {
+				Type:     schema.TypeMap,
 				Optional: true,
-				Default:  "GET",
+				Elem:     &schema.Schema{Type: schema.TypeString},
 			},
-			"request_body": {
+			"response_xpath_extract": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"store_response_body": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			"offload_body_to": {
 				Type:     schema.TypeString,
 				Optional: true,
-				Default:  nil,
+				Default:  "",
+			},
+			"offload_threshold": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "1MiB",
+				ValidateFunc: validateSize,
+			},
+			"offload_s3_region": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "",
+			},
+			"offloaded_to": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"offloaded_sha256": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"extract": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"path": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"sensitive": {
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  false,
+						},
+					},
+				},
+			},
+			"extracted": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"extracted_sensitive": {
+				Type:      schema.TypeMap,
+				Computed:  true,
+				Sensitive: true,
+				Elem:      &schema.Schema{Type: schema.TypeString},
+			},
+			"pipe_response_to": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"pipe_response_timeout": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "30s",
+				ValidateFunc: validateDuration,
+			},
+			"processed_body": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"response_signature_algorithm": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				ValidateFunc: validation.StringInSlice([]string{"", signatureHMACSHA256, signatureEd25519}, false),
+				RequiredWith: []string{"response_signature_key"},
+			},
+			"response_signature_key": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Sensitive:    true,
+				Default:      "",
+				RequiredWith: []string{"response_signature_algorithm"},
+			},
+			"response_signature": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"ignore_request_errors": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"error_code": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"error_message": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"informational_responses": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"code": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"headers": {
+							Type:     schema.TypeMap,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
 			},
 			"response_headers": {
 				Type:     schema.TypeMap,
@@ -62,72 +613,890 @@ func dataSourceRequest() *schema.Resource {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
+			"accept_matched": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"response_body_json": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"response_body_base64": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"response_body_encoding": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"response_sri": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"content_type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"content_encoding": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"charset": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"is_binary_guess": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"redacted_url": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"revocation_status": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"tls_report": tlsReportSchema(),
+			"alpn_protocol": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"alt_svc": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"protocol": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"authority": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"max_age": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"negotiated_protocol": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"deprecated": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"deprecation_date": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"sunset_date": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"remote_addr": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"via_proxy": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
 		},
 	}
+
+	for name, s := range connectionSchema() {
+		resource.Schema[name] = s
+	}
+	return resource
 }
 
 func dataSourceRequestRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 
-	// get vars
-	url := d.Get("url").(string)
-	method := d.Get("request_method").(string)
-	body := []byte(d.Get("request_body").(string))
-	req_headers := d.Get("request_headers").(map[string]interface{})
-	username := d.Get("username").(string)
-	password := d.Get("password").(string)
-
-	insecure := d.Get("insecure").(bool)
+	config := m.(*providerConfig)
 
 	// warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
 
-	// init http request
-	req, err := http.NewRequest(method, url, bytes.NewBuffer(body))
-	if err != nil {
-		return diag.FromErr(err)
+	// arguments without effect are errors in strict mode
+	if config.strict {
+		if violations := strictViolations(d); len(violations) > 0 {
+			return strictDiags(violations)
+		}
 	}
 
-	// set basic auth ?
-	if len(username) > 0 {
-		req.SetBasicAuth(username, password)
+	// send request
+	rc, err := requestConfigFromData(d)
+	if err != nil {
+		return append(diags, requestErrorDiag(&RequestError{Code: errorCodeConfig, Err: err}, diag.Error))
 	}
 
-	// add headers
-	for name, value := range req_headers {
-		req.Header.Set(name, value.(string))
+	// listen for the callback of an asynchronous api, its url is sent in
+	// place of the {{callback_url}} placeholders
+	var listener *callbackListener
+	if settings := callbackFromData(d); settings != nil {
+		listener, err = startCallbackListener(settings)
+		if err != nil {
+			return append(diags, requestErrorDiag(err, diag.Error))
+		}
+		defer listener.close()
+		listener.render(rc)
+		rc.Cacheable = false
 	}
 
-	// init go client and send request
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: insecure,
-		},
+	// create the resource when the request does not find it
+	var rsp *Response
+	var executed_branch string
+	sent_body := rc.Body
+	if create := createIfAbsentFromData(d); create != nil {
+		rsp, executed_branch, err = executeCreateIfAbsent(ctx, config, rc, create)
+		if executed_branch == branchCreated {
+			sent_body = create.Body
+		}
+	} else {
+		rsp, err = ExecuteRequest(ctx, config, rc)
 	}
 
-	client := &http.Client{Transport: tr, Timeout: 10 * time.Second}
-	r, err := client.Do(req)
+	// export the exchange for troubleshooting, failing ones included
+	if har_file := d.Get("har_file").(string); len(har_file) > 0 && rsp != nil {
+		sanitizer := harSanitizer{apiKeyHeader: rc.APIKeyHeader, scrub: newScrubber(d.Get("scrub_patterns").(map[string]interface{}))}
+		if err := writeHAR(har_file, sent_body, rsp, sanitizer); err != nil {
+			return diag.FromErr(err)
+		}
+	}
 	if err != nil {
-		return diag.FromErr(err)
+		if !d.Get("ignore_request_errors").(bool) {
+			return append(diags, requestErrorDiags(err, diag.Error)...)
+		}
+
+		// keep the error in the state and go on
+		d.Set("error_code", errorCode(err))
+		d.Set("error_message", err.Error())
+		d.SetId(requestHash(rc))
+		return append(diags, requestErrorDiags(err, diag.Warning)...)
 	}
-	defer r.Body.Close()
 
-	// read response body
-	rsp_body, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		return diag.FromErr(err)
+	// the result is the request the api sends back
+	callback_body := ""
+	callback_headers := make(map[string]string)
+	if listener != nil {
+		callback, err := listener.wait(ctx)
+		if err != nil {
+			return append(diags, requestErrorDiag(err, diag.Error))
+		}
+		callback_body = string(callback.Body)
+		callback_headers = flattenHeaders(callback.Headers)
+	}
+
+	// a 304 response has no body and a data source has no prior state, the
+	// values derived from the body are empty, the configuration keeps the
+	// previous document
+	extracted, extracted_sensitive := make(map[string]string), make(map[string]string)
+	captures := make(map[string]string)
+	soap_fault, processed_body, signature := "", "", ""
+	if rsp.StatusCode != http.StatusNotModified {
+		// rewrite the body with the registered transformers
+		var steps []transformerStep
+		for _, raw := range d.Get("response_transformer").([]interface{}) {
+			block := raw.(map[string]interface{})
+			step := transformerStep{Name: block["name"].(string), Params: make(map[string]string)}
+			for key, value := range block["params"].(map[string]interface{}) {
+				step.Params[key] = value.(string)
+			}
+			steps = append(steps, step)
+		}
+		if err := transformResponse(ctx, rsp, steps); err != nil {
+			return append(diags, requestErrorDiag(err, diag.Error))
+		}
+
+		// extract fields from the json body
+		extract_blocks := d.Get("extract").([]interface{})
+		for name, path := range d.Get("response_extract").(map[string]interface{}) {
+			extract_blocks = append(extract_blocks, map[string]interface{}{"name": name, "path": path, "sensitive": false})
+		}
+		extracted, extracted_sensitive, err = extractFields(extract_blocks, rsp.Body)
+		if err != nil {
+			return append(diags, requestErrorDiag(err, diag.Error))
+		}
+
+		// extract fields from the xml body, and the fault of soap responses
+		soap_fault, err = extractXMLFields(d.Get("response_xpath_extract").(map[string]interface{}), rsp.Body, extracted, len(d.Get("soap_envelope").(string)) > 0)
+		if err != nil {
+			return append(diags, requestErrorDiag(err, diag.Error))
+		}
+
+		// named groups of the first match of the regex
+		if pattern := d.Get("body_regex").(string); len(pattern) > 0 {
+			re := regexp.MustCompile(pattern)
+			if match := re.FindSubmatch(rsp.Body); match != nil {
+				for i, name := range re.SubexpNames() {
+					if len(name) > 0 {
+						captures[name] = string(match[i])
+					}
+				}
+			}
+		}
+
+		// filter the body with an external program
+		if argv := d.Get("pipe_response_to").([]interface{}); len(argv) > 0 {
+			args := make([]string, len(argv))
+			for i, arg := range argv {
+				args[i], _ = arg.(string)
+			}
+			timeout, _ := time.ParseDuration(d.Get("pipe_response_timeout").(string))
+
+			output, err := pipeBody(ctx, args, rsp.Body, timeout)
+			if err != nil {
+				return append(diags, requestErrorDiag(err, diag.Error))
+			}
+			processed_body = string(output)
+		}
+
+		// sign the body for downstream verification
+		if algorithm := d.Get("response_signature_algorithm").(string); len(algorithm) > 0 {
+			signature, err = signBody(algorithm, d.Get("response_signature_key").(string), rsp.Body)
+			if err != nil {
+				return append(diags, requestErrorDiag(&RequestError{Code: errorCodeConfig, Err: err}, diag.Error))
+			}
+		}
 	}
 
 	// get headers from response
-	rsp_headers := make(map[string]string)
-	for k, v := range r.Header {
-		rsp_headers[k] = strings.Join(v, ", ")
+	rsp_headers := flattenHeaders(rsp.Headers)
+	if len(rc.Session) > 0 {
+		// the cookies of the session stay in memory
+		delete(rsp_headers, "Set-Cookie")
+	}
+
+	// interim responses
+	informational := make([]interface{}, 0, len(rsp.Informational))
+	for _, interim := range rsp.Informational {
+		informational = append(informational, map[string]interface{}{
+			"code":    interim.StatusCode,
+			"headers": flattenHeaders(interim.Headers),
+		})
+	}
+
+	// large bodies are stored outside of the state, only a pointer is kept
+	offloaded_to := ""
+	offloaded_sha256 := ""
+	if settings := offloadFromData(d); settings != nil && int64(len(rsp.Body)) > settings.Threshold {
+		offloaded_to, err = offloadBody(ctx, config, rc, settings, rsp.Body)
+		if err != nil {
+			return append(diags, requestErrorDiag(err, diag.Error))
+		}
+		offloaded_sha256 = fmt.Sprintf("%x", sha256.Sum256(rsp.Body))
+	}
+
+	// describe the payload
+	content := detectContent(rsp.Headers, rsp.Body)
+	// decode json bodies, an invalid document is reported but not fatal,
+	// bodies offloaded or not stored are not copied to the state in any form
+	store_body := d.Get("store_response_body").(bool) && len(offloaded_to) == 0
+	var response_body_json map[string]string
+	if isJSONContentType(content.contentType) && len(rsp.Body) > 0 && store_body {
+		response_body_json, err = flattenJSON(rsp.Body)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "Response body is not valid JSON",
+				Detail:   fmt.Sprintf("response_body_json is empty: %s", err),
+			})
+		}
+	}
+
+	// warn early about apis announcing their deprecation
+	lifecycle := parseAPILifecycle(rsp.Headers)
+	diags = append(diags, lifecycle.diagnostics(rsp.URL, time.Now())...)
+	deprecation_date, sunset_date := "", ""
+	if !lifecycle.deprecatedAt.IsZero() {
+		deprecation_date = lifecycle.deprecatedAt.Format(time.RFC3339)
+	}
+	if !lifecycle.sunset.IsZero() {
+		sunset_date = lifecycle.sunset.Format(time.RFC3339)
+	}
+
+	accept_matched := true
+	if ranges := acceptRanges(d); len(ranges) > 0 {
+		accept_matched = acceptMatches(ranges, content.contentType)
+	}
+
+	// scrub the values written to the state
+	scrub := newScrubber(d.Get("scrub_patterns").(map[string]interface{}))
+	if len(scrub) > 0 {
+		rsp_headers = scrub.applyMap(rsp_headers)
+		processed_body = scrub.apply(processed_body)
+		extracted = scrub.applyMap(extracted)
+		captures = scrub.applyMap(captures)
+		callback_body = scrub.apply(callback_body)
+		callback_headers = scrub.applyMap(callback_headers)
+		response_body_json = scrub.applyMap(response_body_json)
 	}
 
 	// set data resource
-	d.Set("response_code", r.StatusCode)
-	d.Set("response_body", string(rsp_body))
+	d.Set("response_code", rsp.StatusCode)
+	d.Set("not_modified", rsp.StatusCode == http.StatusNotModified)
+	if etag := rsp.Headers.Get("ETag"); len(etag) > 0 || rsp.StatusCode != http.StatusNotModified {
+		d.Set("etag", etag)
+	}
+	if last_modified := rsp.Headers.Get("Last-Modified"); len(last_modified) > 0 || rsp.StatusCode != http.StatusNotModified {
+		d.Set("last_modified", last_modified)
+	}
+	if store_body {
+		stored_body := rsp.Body
+		if len(scrub) > 0 {
+			stored_body = []byte(scrub.apply(string(rsp.Body)))
+		}
+		d.Set("response_body", string(stored_body))
+		d.Set("response_body_base64", base64.StdEncoding.EncodeToString(stored_body))
+	} else {
+		d.Set("response_body", "")
+		d.Set("response_body_base64", "")
+	}
+	if content.isBinary {
+		d.Set("response_body_encoding", "binary")
+	} else {
+		d.Set("response_body_encoding", "utf-8")
+	}
 	d.Set("response_headers", rsp_headers)
-	d.SetId(url)
+	d.Set("offloaded_to", offloaded_to)
+	d.Set("offloaded_sha256", offloaded_sha256)
+	d.Set("processed_body", processed_body)
+	d.Set("response_signature", signature)
+	d.Set("informational_responses", informational)
+	d.Set("extracted", extracted)
+	d.Set("extracted_sensitive", extracted_sensitive)
+	d.Set("captures", captures)
+	d.Set("soap_fault", soap_fault)
+	d.Set("callback_body", callback_body)
+	d.Set("callback_headers", callback_headers)
+	d.Set("response_body_json", response_body_json)
+	d.Set("response_sri", subresourceIntegrity(rsp.Body))
+	d.Set("accept_matched", accept_matched)
+	d.Set("content_type", content.contentType)
+	d.Set("content_encoding", content.contentEncoding)
+	d.Set("charset", content.charset)
+	d.Set("is_binary_guess", content.isBinary)
+	d.Set("executed_branch", executed_branch)
+	d.Set("request_body_preview", bodyPreview(rc.Body, d.Get("request_body_preview_length").(int)))
+	d.Set("request_body_sha256", fmt.Sprintf("%x", sha256.Sum256(rc.Body)))
+	d.Set("redacted_url", rsp.URL)
+	d.Set("revocation_status", rsp.RevocationStatus)
+	d.Set("tls_report", flattenTLSReport(rsp.TLSReport))
+	d.Set("negotiated_protocol", rsp.NegotiatedProtocol())
+	d.Set("alpn_protocol", rsp.ALPNProtocol)
+	d.Set("deprecated", lifecycle.deprecated)
+	d.Set("deprecation_date", deprecation_date)
+	d.Set("sunset_date", sunset_date)
+	d.Set("alt_svc", flattenAltSvc(parseAltSvc(rsp.Headers)))
+	d.Set("remote_addr", rsp.RemoteAddr)
+	d.Set("via_proxy", rsp.ViaProxy)
+	d.Set("error_code", "")
+	d.Set("error_message", "")
+	d.SetId(requestHash(rc))
 
 	return diags
 }
+
+// requestConfigFromData builds the request from the data source arguments
+func requestConfigFromData(d *schema.ResourceData) (*RequestConfig, error) {
+	req_headers := make(map[string]string)
+	for name, value := range d.Get("request_headers").(map[string]interface{}) {
+		req_headers[name] = value.(string)
+	}
+
+	// an explicit Accept header takes precedence
+	if ranges := acceptRanges(d); len(ranges) > 0 && len(headerValue(req_headers, "Accept")) == 0 {
+		req_headers["Accept"] = renderAccept(ranges)
+	}
+	// conditional request, the server answers 304 when unchanged
+	if etag := d.Get("etag").(string); len(etag) > 0 && len(headerValue(req_headers, "If-None-Match")) == 0 {
+		req_headers["If-None-Match"] = etag
+	}
+	if last_modified := d.Get("last_modified").(string); len(last_modified) > 0 && len(headerValue(req_headers, "If-Modified-Since")) == 0 {
+		req_headers["If-Modified-Since"] = last_modified
+	}
+	if name := d.Get("api_version_header").(string); len(name) > 0 && len(headerValue(req_headers, name)) == 0 {
+		req_headers[name] = d.Get("api_version").(string)
+	}
+
+	// values are checked by the schema validation
+	timeout, _ := time.ParseDuration(d.Get("timeout").(string))
+	max_body_size, _ := parseSize(d.Get("max_response_body_size").(string))
+
+	var date_headers []DateHeader
+	for _, raw := range d.Get("date_header").([]interface{}) {
+		header := raw.(map[string]interface{})
+		location, _ := time.LoadLocation(header["timezone"].(string))
+		date_headers = append(date_headers, DateHeader{
+			Name:     header["name"].(string),
+			Format:   header["format"].(string),
+			Location: location,
+		})
+	}
+
+	// structured json patch, serialized as the body
+	body := []byte(d.Get("request_body").(string))
+	if raw_operations := d.Get("json_patch").([]interface{}); len(raw_operations) > 0 {
+		var operations []jsonPatchOperation
+		for _, raw := range raw_operations {
+			block := raw.(map[string]interface{})
+			operation := jsonPatchOperation{
+				Op:   block["op"].(string),
+				Path: block["path"].(string),
+				From: block["from"].(string),
+			}
+			if value := block["value"].(string); len(value) > 0 {
+				operation.Value = json.RawMessage(value)
+			}
+			operations = append(operations, operation)
+		}
+
+		var err error
+		body, err = marshalJSONPatch(operations)
+		if err != nil {
+			return nil, err
+		}
+		if len(headerValue(req_headers, "Content-Type")) == 0 {
+			req_headers["Content-Type"] = jsonPatchContentType
+		}
+	}
+
+	// form bodies, the multipart boundary is set in the Content-Type header
+	if fields := d.Get("request_body_form").(map[string]interface{}); len(fields) > 0 {
+		form := make(map[string]string, len(fields))
+		for name, value := range fields {
+			form[name] = value.(string)
+		}
+		body = encodeForm(form)
+		if len(headerValue(req_headers, "Content-Type")) == 0 {
+			req_headers["Content-Type"] = formContentType
+		}
+	}
+	if raw_parts := d.Get("request_body_multipart").([]interface{}); len(raw_parts) > 0 {
+		var parts []multipartPart
+		for _, raw := range raw_parts {
+			block := raw.(map[string]interface{})
+			parts = append(parts, multipartPart{
+				Name:        block["name"].(string),
+				Content:     block["content"].(string),
+				File:        block["file"].(string),
+				Filename:    block["filename"].(string),
+				ContentType: block["content_type"].(string),
+			})
+		}
+
+		var content_type string
+		var err error
+		body, content_type, err = encodeMultipart(parts)
+		if err != nil {
+			return nil, err
+		}
+		for name := range req_headers {
+			if strings.EqualFold(name, "Content-Type") {
+				delete(req_headers, name)
+			}
+		}
+		req_headers["Content-Type"] = content_type
+	}
+
+	var assertions []Assertion
+	for _, raw := range d.Get("assertions").([]interface{}) {
+		block := raw.(map[string]interface{})
+		assertion := Assertion{
+			Type:   block["type"].(string),
+			Header: block["header"].(string),
+			Path:   block["path"].(string),
+			Value:  block["value"].(string),
+		}
+		if err := assertion.validate(); err != nil {
+			return nil, err
+		}
+		assertions = append(assertions, assertion)
+	}
+
+	// soap requests, the body is the content of the soap body
+	soap_version := d.Get("soap_envelope").(string)
+	soap_action := d.Get("soap_action").(string)
+	if len(soap_version) > 0 {
+		body = soapEnvelope(soap_version, body)
+		if len(headerValue(req_headers, "Content-Type")) == 0 {
+			req_headers["Content-Type"] = soapContentType(soap_version, soap_action)
+		}
+	}
+	if len(soap_action) > 0 && soap_version != soapVersion12 && len(headerValue(req_headers, "SOAPAction")) == 0 {
+		req_headers["SOAPAction"] = `"` + soap_action + `"`
+	}
+
+	var retry_on_status_codes []int
+	for _, code := range d.Get("retry_on_status_codes").([]interface{}) {
+		retry_on_status_codes = append(retry_on_status_codes, code.(int))
+	}
+
+	var allowed_networks []*net.IPNet
+	for _, cidr := range d.Get("assert_remote_ip_in_cidrs").([]interface{}) {
+		_, network, _ := net.ParseCIDR(cidr.(string))
+		allowed_networks = append(allowed_networks, network)
+	}
+
+	rc := &RequestConfig{
+		URL:                       d.Get("url").(string),
+		Method:                    d.Get("request_method").(string),
+		Headers:                   req_headers,
+		Body:                      body,
+		Session:                   d.Get("session").(string),
+		DateHeaders:               date_headers,
+		CompressBody:              d.Get("compress_request_body").(string),
+		Timeout:                   timeout,
+		MaxBodySize:               max_body_size,
+		UseSRVLookup:              d.Get("use_srv_lookup").(bool),
+		AllowedRemoteNetworks:     allowed_networks,
+		HeadersOnly:               d.Get("headers_only").(bool),
+		PreflightHead:             d.Get("preflight_head").(bool),
+		PreflightContentType:      d.Get("preflight_content_type").(string),
+		PreflightMaxContentLength: int64(d.Get("preflight_max_content_length").(int)),
+		SuccessWhen:               d.Get("success_when").(string),
+		Assertions:                assertions,
+		RetryAttempts:             d.Get("retry_attempts").(int),
+		RetryMinDelay:             time.Duration(d.Get("retry_min_delay_ms").(int)) * time.Millisecond,
+		RetryMaxDelay:             time.Duration(d.Get("retry_max_delay_ms").(int)) * time.Millisecond,
+		RetryOnStatusCodes:        retry_on_status_codes,
+		RetryUntilHeader:          retryUntilHeaderFromData(d),
+		RetryNonIdempotent:        d.Get("retry_non_idempotent").(bool),
+		Cacheable:                 createIfAbsentFromData(d) == nil,
+	}
+	if err := connectionFromData(d, rc); err != nil {
+		return nil, err
+	}
+	return rc, nil
+}
+
+// authRequestFromData builds the login request of the auth_request block
+func authRequestFromData(d *schema.ResourceData) *AuthRequest {
+	blocks := d.Get("auth_request").([]interface{})
+	if len(blocks) == 0 || blocks[0] == nil {
+		return nil
+	}
+	block := blocks[0].(map[string]interface{})
+
+	auth := &AuthRequest{
+		URL:     block["url"].(string),
+		Method:  block["request_method"].(string),
+		Headers: make(map[string]string),
+		Body:    []byte(block["request_body"].(string)),
+	}
+	for name, value := range block["request_headers"].(map[string]interface{}) {
+		auth.Headers[name] = value.(string)
+	}
+	for _, raw := range block["header"].([]interface{}) {
+		mapping := raw.(map[string]interface{})
+		auth.Mappings = append(auth.Mappings, AuthHeaderMapping{
+			Name:         mapping["name"].(string),
+			FromHeader:   mapping["from_header"].(string),
+			FromJSONPath: mapping["from_json_path"].(string),
+			Prefix:       mapping["prefix"].(string),
+		})
+	}
+	return auth
+}
+
+// createIfAbsentFromData builds the create request of the create_if_absent block
+func createIfAbsentFromData(d *schema.ResourceData) *CreateIfAbsent {
+	blocks := d.Get("create_if_absent").([]interface{})
+	if len(blocks) == 0 || blocks[0] == nil {
+		return nil
+	}
+	block := blocks[0].(map[string]interface{})
+
+	create := &CreateIfAbsent{
+		URL:     block["url"].(string),
+		Method:  block["request_method"].(string),
+		Headers: make(map[string]string),
+		Body:    []byte(block["request_body"].(string)),
+	}
+	for name, value := range block["request_headers"].(map[string]interface{}) {
+		create.Headers[name] = value.(string)
+	}
+	return create
+}
+
+// oauth2FromData builds the settings of the oauth2 block
+func oauth2FromData(d *schema.ResourceData) *OAuth2 {
+	blocks := d.Get("oauth2").([]interface{})
+	if len(blocks) == 0 || blocks[0] == nil {
+		return nil
+	}
+	block := blocks[0].(map[string]interface{})
+
+	oauth2 := &OAuth2{
+		TokenURL:     block["token_url"].(string),
+		ClientID:     block["client_id"].(string),
+		ClientSecret: block["client_secret"].(string),
+		Audience:     block["audience"].(string),
+		AuthStyle:    block["auth_style"].(string),
+	}
+	for _, scope := range block["scopes"].([]interface{}) {
+		oauth2.Scopes = append(oauth2.Scopes, scope.(string))
+	}
+	return oauth2
+}
+
+// retryUntilHeaderFromData builds the condition of the retry_until_header block
+func retryUntilHeaderFromData(d *schema.ResourceData) *HeaderCondition {
+	blocks := d.Get("retry_until_header").([]interface{})
+	if len(blocks) == 0 || blocks[0] == nil {
+		return nil
+	}
+	block := blocks[0].(map[string]interface{})
+
+	condition := &HeaderCondition{
+		Name:  block["name"].(string),
+		Value: block["value"].(string),
+	}
+	if pattern := block["pattern"].(string); len(pattern) > 0 {
+		// the pattern is checked by the schema validation
+		condition.Pattern = regexp.MustCompile(pattern)
+	}
+	return condition
+}
+
+// keystoneFromData builds the settings of the keystone block
+func keystoneFromData(d *schema.ResourceData) *KeystoneAuth {
+	blocks := d.Get("keystone").([]interface{})
+	if len(blocks) == 0 || blocks[0] == nil {
+		return nil
+	}
+	block := blocks[0].(map[string]interface{})
+
+	return &KeystoneAuth{
+		AuthURL:                     block["auth_url"].(string),
+		UserDomainName:              block["user_domain_name"].(string),
+		ProjectID:                   block["project_id"].(string),
+		ProjectName:                 block["project_name"].(string),
+		ProjectDomainName:           block["project_domain_name"].(string),
+		ApplicationCredentialID:     block["application_credential_id"].(string),
+		ApplicationCredentialSecret: block["application_credential_secret"].(string),
+	}
+}
+
+// offloadFromData builds the settings of the body offload, nil without
+// offload_body_to
+func offloadFromData(d *schema.ResourceData) *OffloadSettings {
+	destination := d.Get("offload_body_to").(string)
+	if len(destination) == 0 {
+		return nil
+	}
+	threshold, _ := parseSize(d.Get("offload_threshold").(string))
+
+	return &OffloadSettings{
+		Destination: destination,
+		Threshold:   threshold,
+		S3Region:    d.Get("offload_s3_region").(string),
+	}
+}
+
+// callbackFromData builds the settings of the callback block
+func callbackFromData(d *schema.ResourceData) *CallbackSettings {
+	blocks := d.Get("callback").([]interface{})
+	if len(blocks) == 0 || blocks[0] == nil {
+		return nil
+	}
+	block := blocks[0].(map[string]interface{})
+	timeout, _ := time.ParseDuration(block["timeout"].(string))
+
+	return &CallbackSettings{
+		ListenAddress: block["listen_address"].(string),
+		PublicURL:     block["public_url"].(string),
+		CertFile:      block["tls_cert_file"].(string),
+		KeyFile:       block["tls_key_file"].(string),
+		Timeout:       timeout,
+	}
+}
+
+// ntlmFromData builds the settings of the ntlm block
+func ntlmFromData(d *schema.ResourceData) *NTLMAuth {
+	blocks := d.Get("ntlm").([]interface{})
+	if len(blocks) == 0 || blocks[0] == nil {
+		return nil
+	}
+	block := blocks[0].(map[string]interface{})
+
+	return &NTLMAuth{
+		Domain:      block["domain"].(string),
+		Workstation: block["workstation"].(string),
+	}
+}
+
+// awsSigV4FromData builds the signing settings of the aws_sigv4 block
+func awsSigV4FromData(d *schema.ResourceData) *AWSSigV4 {
+	blocks := d.Get("aws_sigv4").([]interface{})
+	if len(blocks) == 0 || blocks[0] == nil {
+		return nil
+	}
+	block := blocks[0].(map[string]interface{})
+
+	return &AWSSigV4{
+		Region:                block["region"].(string),
+		Service:               block["service"].(string),
+		AccessKey:             block["access_key"].(string),
+		SecretKey:             block["secret_key"].(string),
+		SessionToken:          block["session_token"].(string),
+		UseDefaultCredentials: block["use_default_credentials"].(bool),
+	}
+}
+
+// jsonPointerPattern validates the RFC 6901 pointers of the json_patch blocks
+var jsonPointerPattern = regexp.MustCompile(`^(/([^~]|~[01])*)*$`)
+
+// mediaRangePattern validates the media ranges of the accept blocks
+var mediaRangePattern = regexp.MustCompile(`^([A-Za-z0-9!#$&^_.+-]+|\*)/([A-Za-z0-9!#$&^_.+-]+|\*)$`)
+
+func acceptRanges(d *schema.ResourceData) []acceptRange {
+	var ranges []acceptRange
+	for _, raw := range d.Get("accept").([]interface{}) {
+		block := raw.(map[string]interface{})
+		ranges = append(ranges, acceptRange{mediaType: block["type"].(string), quality: block["quality"].(float64)})
+	}
+	return ranges
+}
+
+// flattenHeaders joins multiple values of a header, as a map of strings
+func flattenHeaders(headers http.Header) map[string]string {
+	flat := make(map[string]string)
+	for k, v := range headers {
+		flat[k] = strings.Join(v, ", ")
+	}
+	return flat
+}
+
+// extractFields evaluates the extract blocks against the json body, values
+// are split between the plain and the sensitive maps
+func extractFields(blocks []interface{}, body []byte) (map[string]string, map[string]string, error) {
+	extracted := make(map[string]string)
+	extracted_sensitive := make(map[string]string)
+	if len(blocks) == 0 {
+		return extracted, extracted_sensitive, nil
+	}
+
+	doc, err := decodeJSON(body)
+	if err != nil {
+		return nil, nil, newRequestError(errorCodeBodyDecode, "extract: response body is not valid json: %s", err)
+	}
+
+	for _, raw := range blocks {
+		block := raw.(map[string]interface{})
+		name, path := block["name"].(string), block["path"].(string)
+		if _, ok := extracted[name]; ok {
+			return nil, nil, newRequestError(errorCodeConfig, "extract %s: the name is extracted more than once", name)
+		}
+		if _, ok := extracted_sensitive[name]; ok {
+			return nil, nil, newRequestError(errorCodeConfig, "extract %s: the name is extracted more than once", name)
+		}
+
+		value, found, err := jsonPathLookup(doc, path)
+		if err != nil {
+			return nil, nil, &RequestError{Code: errorCodeConfig, Err: err}
+		}
+		if !found {
+			return nil, nil, newRequestError(errorCodeBodyDecode, "extract %s: %s not found in response body", name, path)
+		}
+
+		if block["sensitive"].(bool) {
+			extracted_sensitive[name] = jsonValueString(value)
+		} else {
+			extracted[name] = jsonValueString(value)
+		}
+	}
+	return extracted, extracted_sensitive, nil
+}
+
+// extractXMLFields evaluates the xpath expressions against the xml body and
+// adds the values to extracted, it returns the soap fault of the response
+func extractXMLFields(xpaths map[string]interface{}, body []byte, extracted map[string]string, soap bool) (string, error) {
+	if len(xpaths) == 0 && !soap {
+		return "", nil
+	}
+
+	doc, err := parseXML(body)
+	if err != nil {
+		if len(xpaths) == 0 {
+			// only xml responses are checked for soap faults
+			return "", nil
+		}
+		return "", newRequestError(errorCodeBodyDecode, "response_xpath_extract: response body is not valid xml: %s", err)
+	}
+
+	for name, path := range xpaths {
+		if _, ok := extracted[name]; ok {
+			return "", newRequestError(errorCodeConfig, "extract %s: the name is extracted more than once", name)
+		}
+		value, found, err := xpathLookup(doc, path.(string))
+		if err != nil {
+			return "", &RequestError{Code: errorCodeConfig, Err: err}
+		}
+		if !found {
+			return "", newRequestError(errorCodeBodyDecode, "extract %s: %s not found in response body", name, path)
+		}
+		extracted[name] = value
+	}
+	return soapFault(doc), nil
+}
+
+// bodyPreview returns the first bytes of a body for audit purpose, with the
+// total size when truncated. The values of a json body are masked so that
+// only its structure is recorded, other bodies are reduced to their size.
+func bodyPreview(body []byte, length int) string {
+	if len(body) == 0 {
+		return ""
+	}
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return fmt.Sprintf("(%d bytes)", len(body))
+	}
+	masked, _ := json.Marshal(maskJSONValues(doc))
+	if len(masked) <= length {
+		return string(masked)
+	}
+	return fmt.Sprintf("%s... (%d bytes)", masked[:length], len(body))
+}
+
+// maskJSONValues replaces the scalar values of a decoded json document
+func maskJSONValues(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = maskJSONValues(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = maskJSONValues(item)
+		}
+		return v
+	case nil:
+		return nil
+	default:
+		return "***"
+	}
+}
+
+func validateTimezone(v interface{}, k string) ([]string, []error) {
+	if _, err := time.LoadLocation(v.(string)); err != nil {
+		return nil, []error{fmt.Errorf("%s: %s", k, err)}
+	}
+	return nil, nil
+}
+
+func validateExpression(v interface{}, k string) ([]string, []error) {
+	if expr := v.(string); len(expr) > 0 {
+		if _, err := parseExpression(expr); err != nil {
+			return nil, []error{fmt.Errorf("%s: %s", k, err)}
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,78 @@
+package httpclient
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceRunStats() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceRunStatsRead,
+		Schema: map[string]*schema.Schema{
+			"request_count": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"error_count": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"error_rate": {
+				Type:     schema.TypeFloat,
+				Computed: true,
+			},
+			"errors_by_code": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeInt},
+			},
+			"response_bytes": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"latency_p50_ms": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"latency_p90_ms": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"latency_p99_ms": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"latency_max_ms": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceRunStatsRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	stats := m.(*providerConfig).stats.snapshot()
+
+	error_rate := 0.0
+	if stats.requests > 0 {
+		error_rate = float64(stats.errorCount) / float64(stats.requests)
+	}
+
+	// set data resource
+	d.Set("request_count", stats.requests)
+	d.Set("error_count", stats.errorCount)
+	d.Set("error_rate", error_rate)
+	d.Set("errors_by_code", stats.errors)
+	d.Set("response_bytes", int(stats.responseBytes))
+	d.Set("latency_p50_ms", int(stats.percentile(50).Milliseconds()))
+	d.Set("latency_p90_ms", int(stats.percentile(90).Milliseconds()))
+	d.Set("latency_p99_ms", int(stats.percentile(99).Milliseconds()))
+	d.Set("latency_max_ms", int(stats.percentile(100).Milliseconds()))
+	d.SetId(strconv.FormatInt(time.Now().UnixNano(), 10))
+
+	return nil
+}
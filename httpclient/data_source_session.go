@@ -0,0 +1,43 @@
+package httpclient
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceSession() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceSessionRead,
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"reset": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+		},
+	}
+}
+
+func dataSourceSessionRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*providerConfig)
+
+	// get vars
+	name := d.Get("name").(string)
+
+	// start with an empty cookie jar
+	if d.Get("reset").(bool) {
+		config.sessions.reset(name)
+	}
+	config.sessions.jar(name)
+
+	// set data resource, the id is the session of the requests
+	d.SetId(name)
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+// apiLifecycle is the deprecation announced by the response headers
+type apiLifecycle struct {
+	deprecated bool
+	// deprecatedAt and sunset are zero when not announced
+	deprecatedAt time.Time
+	sunset       time.Time
+}
+
+// parseAPILifecycle reads the Deprecation header, a structured date such as
+// `@1688169599` (RFC 9745) or the legacy `true` and HTTP-date forms, and the
+// Sunset HTTP-date (RFC 8594)
+func parseAPILifecycle(headers http.Header) apiLifecycle {
+	var lifecycle apiLifecycle
+
+	if value := strings.TrimSpace(headers.Get("Deprecation")); len(value) > 0 {
+		switch {
+		case strings.HasPrefix(value, "@"):
+			if seconds, err := strconv.ParseInt(value[1:], 10, 64); err == nil {
+				lifecycle.deprecated = true
+				lifecycle.deprecatedAt = time.Unix(seconds, 0).UTC()
+			}
+		case strings.EqualFold(value, "true"):
+			lifecycle.deprecated = true
+		default:
+			if date, err := http.ParseTime(value); err == nil {
+				lifecycle.deprecated = true
+				lifecycle.deprecatedAt = date.UTC()
+			}
+		}
+	}
+
+	if value := strings.TrimSpace(headers.Get("Sunset")); len(value) > 0 {
+		if date, err := http.ParseTime(value); err == nil {
+			lifecycle.sunset = date.UTC()
+		}
+	}
+	return lifecycle
+}
+
+// diagnostics warns about the deprecation of the requested API
+func (l apiLifecycle) diagnostics(url string, now time.Time) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if l.deprecated {
+		detail := fmt.Sprintf("%s announced its deprecation with the Deprecation response header", url)
+		if !l.deprecatedAt.IsZero() {
+			detail = fmt.Sprintf("%s is deprecated since %s", url, l.deprecatedAt.Format(time.RFC3339))
+		}
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "API is deprecated",
+			Detail:   detail + ", check the documentation of the API for a replacement.",
+		})
+	}
+
+	if !l.sunset.IsZero() {
+		detail := fmt.Sprintf("%s will stop responding on %s (in %d days)", url, l.sunset.Format(time.RFC3339), int(l.sunset.Sub(now).Hours()/24))
+		if !l.sunset.After(now) {
+			detail = fmt.Sprintf("%s was expected to stop responding on %s", url, l.sunset.Format(time.RFC3339))
+		}
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "API sunset announced",
+			Detail:   detail + ", according to the Sunset response header.",
+		})
+	}
+	return diags
+}
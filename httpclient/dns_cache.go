@@ -0,0 +1,80 @@
+package httpclient
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// dnsCache caches host name lookups for the lifetime of the provider, so
+// many requests to the same hosts do not hammer the resolvers
+type dnsCache struct {
+	ttl     time.Duration
+	exclude map[string]bool
+	dialer  *net.Dialer
+
+	mu      sync.Mutex
+	entries map[string]dnsCacheEntry
+}
+
+type dnsCacheEntry struct {
+	addrs   []net.IPAddr
+	expires time.Time
+}
+
+func newDNSCache(ttl time.Duration, exclude []string) *dnsCache {
+	cache := &dnsCache{
+		ttl:     ttl,
+		exclude: make(map[string]bool),
+		dialer:  &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second},
+		entries: make(map[string]dnsCacheEntry),
+	}
+	for _, host := range exclude {
+		cache.exclude[host] = true
+	}
+	return cache
+}
+
+// lookup returns the addresses of the host, from the cache when not expired
+func (c *dnsCache) lookup(ctx context.Context, host string) ([]net.IPAddr, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[host]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.addrs, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[host] = dnsCacheEntry{addrs: addrs, expires: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+
+	return addrs, nil
+}
+
+// DialContext dials the cached addresses of the host in turn
+func (c *dnsCache) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || net.ParseIP(host) != nil || c.exclude[host] {
+		return c.dialer.DialContext(ctx, network, address)
+	}
+
+	addrs, err := c.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var conn net.Conn
+	for _, addr := range addrs {
+		conn, err = c.dialer.DialContext(ctx, network, net.JoinHostPort(addr.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
@@ -0,0 +1,35 @@
+package httpclient
+
+import (
+	"context"
+	"net"
+)
+
+// dialFunc is the signature of net.Dialer.DialContext
+type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// restrictRemoteAddr wraps dial to reject connections established to an
+// address outside the allowed networks, before anything is sent on them
+func restrictRemoteAddr(dial dialFunc, allowed []*net.IPNet) dialFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, err := dial(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+
+		remote_addr := conn.RemoteAddr().String()
+		host, _, err := net.SplitHostPort(remote_addr)
+		if err != nil {
+			host = remote_addr
+		}
+		ip := net.ParseIP(host)
+		for _, network := range allowed {
+			if ip != nil && network.Contains(ip) {
+				return conn, nil
+			}
+		}
+
+		conn.Close()
+		return nil, newRequestError(errorCodePolicy, "connection to %s established to %s, outside of the allowed networks", address, remote_addr)
+	}
+}
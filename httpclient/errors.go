@@ -0,0 +1,88 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+// stable error codes reported in diagnostics and in the error_code attribute
+const (
+	errorCodeTimeout    = "TIMEOUT"
+	errorCodeDNS        = "DNS"
+	errorCodeTLSVerify  = "TLS_VERIFY"
+	errorCodeClientAuth = "TLS_CLIENT_AUTH"
+	errorCodeConnection = "CONNECTION"
+	errorCodeStatus     = "STATUS"
+	errorCodeBodyDecode = "BODY_DECODE"
+	errorCodePolicy     = "POLICY"
+	errorCodeAssertion  = "ASSERTION"
+	errorCodeConfig     = "CONFIG"
+	errorCodeUnknown    = "UNKNOWN"
+)
+
+// RequestError is an error with a machine-readable code
+type RequestError struct {
+	Code string
+	Err  error
+}
+
+func (e *RequestError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// newRequestError returns a formatted error with the given code
+func newRequestError(code string, format string, a ...interface{}) error {
+	return &RequestError{Code: code, Err: fmt.Errorf(format, a...)}
+}
+
+// errorCode returns the code of an error, errors raised by the network or
+// tls stacks are classified by their type
+func errorCode(err error) string {
+	var request_err *RequestError
+	if errors.As(err, &request_err) {
+		return request_err.Code
+	}
+
+	var dns_err *net.DNSError
+	var net_err net.Error
+	var verify_err *tls.CertificateVerificationError
+	var unknown_authority_err x509.UnknownAuthorityError
+	var hostname_err x509.HostnameError
+	var invalid_err x509.CertificateInvalidError
+	var op_err *net.OpError
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return errorCodeTimeout
+	case errors.As(err, &dns_err):
+		return errorCodeDNS
+	case errors.As(err, &net_err) && net_err.Timeout():
+		return errorCodeTimeout
+	case errors.As(err, &verify_err), errors.As(err, &unknown_authority_err),
+		errors.As(err, &hostname_err), errors.As(err, &invalid_err):
+		return errorCodeTLSVerify
+	case errors.As(err, &op_err):
+		return errorCodeConnection
+	}
+	return errorCodeUnknown
+}
+
+// requestErrorDiag converts an error to a diagnostic carrying its code
+func requestErrorDiag(err error, severity diag.Severity) diag.Diagnostic {
+	code := errorCode(err)
+	return diag.Diagnostic{
+		Severity: severity,
+		Summary:  fmt.Sprintf("HTTP request failed (%s)", code),
+		Detail:   fmt.Sprintf("error_code: %s\n%s", code, err),
+	}
+}
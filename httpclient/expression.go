@@ -0,0 +1,468 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The success_when attribute accepts a small boolean expression evaluated
+// against the response, for example:
+//
+//	code == 200 && jsonpath("$.status") == "ready"
+//
+// Supported operands are number, string and bool literals, the `code` and
+// `body` variables and the `header(name)`, `jsonpath(path)` and
+// `contains(s, substr)` functions. Operators are ==, !=, <, <=, >, >=, &&,
+// || and !, with parentheses for grouping.
+
+// expressionContext holds the response values an expression is evaluated against
+type expressionContext struct {
+	code    int
+	headers http.Header
+	body    []byte
+
+	doc     interface{}
+	decoded bool
+	docErr  error
+}
+
+func (c *expressionContext) jsonDocument() (interface{}, error) {
+	if !c.decoded {
+		c.decoded = true
+		c.docErr = json.Unmarshal(c.body, &c.doc)
+	}
+	return c.doc, c.docErr
+}
+
+type expression interface {
+	eval(c *expressionContext) (interface{}, error)
+}
+
+// parseExpression compiles the expression, so syntax errors can be reported at plan time
+func parseExpression(input string) (expression, error) {
+	tokens, err := tokenizeExpression(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &expressionParser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q at end of expression", p.tokens[p.pos].text)
+	}
+	return expr, nil
+}
+
+// evalBoolExpression parses and evaluates the expression, the result must be a bool
+func evalBoolExpression(input string, c *expressionContext) (bool, error) {
+	expr, err := parseExpression(input)
+	if err != nil {
+		return false, err
+	}
+
+	value, err := expr.eval(c)
+	if err != nil {
+		return false, err
+	}
+
+	result, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression must evaluate to a bool, got %v", value)
+	}
+	return result, nil
+}
+
+// tokenizer
+
+const (
+	tokenNumber = iota
+	tokenString
+	tokenIdent
+	tokenOperator
+)
+
+type expressionToken struct {
+	kind  int
+	text  string
+	value interface{}
+}
+
+func tokenizeExpression(input string) ([]expressionToken, error) {
+	var tokens []expressionToken
+	for i := 0; i < len(input); {
+		ch := rune(input[i])
+		switch {
+		case unicode.IsSpace(ch):
+			i++
+		case ch == '"' || ch == '\'':
+			end := i + 1
+			var sb strings.Builder
+			for ; end < len(input) && rune(input[end]) != ch; end++ {
+				if input[end] == '\\' && end+1 < len(input) {
+					end++
+				}
+				sb.WriteByte(input[end])
+			}
+			if end >= len(input) {
+				return nil, fmt.Errorf("unterminated string starting at offset %d", i)
+			}
+			tokens = append(tokens, expressionToken{kind: tokenString, text: input[i : end+1], value: sb.String()})
+			i = end + 1
+		case unicode.IsDigit(ch):
+			end := i
+			for end < len(input) && (unicode.IsDigit(rune(input[end])) || input[end] == '.') {
+				end++
+			}
+			number, err := strconv.ParseFloat(input[i:end], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q", input[i:end])
+			}
+			tokens = append(tokens, expressionToken{kind: tokenNumber, text: input[i:end], value: number})
+			i = end
+		case unicode.IsLetter(ch) || ch == '_':
+			end := i
+			for end < len(input) && (unicode.IsLetter(rune(input[end])) || unicode.IsDigit(rune(input[end])) || input[end] == '_') {
+				end++
+			}
+			tokens = append(tokens, expressionToken{kind: tokenIdent, text: input[i:end]})
+			i = end
+		default:
+			op := ""
+			for _, candidate := range []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")", ","} {
+				if strings.HasPrefix(input[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", ch, i)
+			}
+			tokens = append(tokens, expressionToken{kind: tokenOperator, text: op})
+			i += len(op)
+		}
+	}
+	return tokens, nil
+}
+
+// parser
+
+type expressionParser struct {
+	tokens []expressionToken
+	pos    int
+}
+
+func (p *expressionParser) peekOperator(ops ...string) string {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokenOperator {
+		return ""
+	}
+	for _, op := range ops {
+		if p.tokens[p.pos].text == op {
+			return op
+		}
+	}
+	return ""
+}
+
+func (p *expressionParser) expectOperator(op string) error {
+	if p.peekOperator(op) == "" {
+		if p.pos >= len(p.tokens) {
+			return fmt.Errorf("expected %q at end of expression", op)
+		}
+		return fmt.Errorf("expected %q, got %q", op, p.tokens[p.pos].text)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *expressionParser) parseOr() (expression, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOperator("||") != "" {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalExpression{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *expressionParser) parseAnd() (expression, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOperator("&&") != "" {
+		p.pos++
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalExpression{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *expressionParser) parseComparison() (expression, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	if op := p.peekOperator("==", "!=", "<=", ">=", "<", ">"); op != "" {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &comparisonExpression{op: op, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *expressionParser) parseUnary() (expression, error) {
+	if p.peekOperator("!") != "" {
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpression{operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *expressionParser) parsePrimary() (expression, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	token := p.tokens[p.pos]
+	p.pos++
+
+	switch token.kind {
+	case tokenNumber, tokenString:
+		return &literalExpression{value: token.value}, nil
+	case tokenOperator:
+		if token.text != "(" {
+			return nil, fmt.Errorf("unexpected %q", token.text)
+		}
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return expr, p.expectOperator(")")
+	}
+
+	// identifiers: literals, variables and function calls
+	switch token.text {
+	case "true":
+		return &literalExpression{value: true}, nil
+	case "false":
+		return &literalExpression{value: false}, nil
+	case "code", "body":
+		return &variableExpression{name: token.text}, nil
+	}
+
+	arity, ok := expressionFunctions[token.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown identifier %q", token.text)
+	}
+	if err := p.expectOperator("("); err != nil {
+		return nil, err
+	}
+
+	call := &callExpression{name: token.text}
+	for len(call.args) < arity {
+		if len(call.args) > 0 {
+			if err := p.expectOperator(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+	}
+	return call, p.expectOperator(")")
+}
+
+// nodes
+
+// expressionFunctions lists the available functions with their number of arguments
+var expressionFunctions = map[string]int{
+	"header":   1,
+	"jsonpath": 1,
+	"contains": 2,
+}
+
+type literalExpression struct {
+	value interface{}
+}
+
+func (e *literalExpression) eval(c *expressionContext) (interface{}, error) {
+	return e.value, nil
+}
+
+type variableExpression struct {
+	name string
+}
+
+func (e *variableExpression) eval(c *expressionContext) (interface{}, error) {
+	if e.name == "code" {
+		return float64(c.code), nil
+	}
+	return string(c.body), nil
+}
+
+type callExpression struct {
+	name string
+	args []expression
+}
+
+func (e *callExpression) eval(c *expressionContext) (interface{}, error) {
+	var args []string
+	for _, arg := range e.args {
+		value, err := arg.eval(c)
+		if err != nil {
+			return nil, err
+		}
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s() expects string arguments, got %v", e.name, value)
+		}
+		args = append(args, s)
+	}
+
+	switch e.name {
+	case "header":
+		return c.headers.Get(args[0]), nil
+	case "contains":
+		return strings.Contains(args[0], args[1]), nil
+	}
+
+	doc, err := c.jsonDocument()
+	if err != nil {
+		return nil, fmt.Errorf("jsonpath(): response body is not valid json: %w", err)
+	}
+	value, found, err := jsonPathLookup(doc, args[0])
+	if err != nil || !found {
+		return nil, err
+	}
+	return value, nil
+}
+
+type notExpression struct {
+	operand expression
+}
+
+func (e *notExpression) eval(c *expressionContext) (interface{}, error) {
+	value, err := e.operand.eval(c)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return nil, fmt.Errorf("operator ! expects a bool, got %v", value)
+	}
+	return !b, nil
+}
+
+type logicalExpression struct {
+	op          string
+	left, right expression
+}
+
+func (e *logicalExpression) eval(c *expressionContext) (interface{}, error) {
+	left, err := e.left.eval(c)
+	if err != nil {
+		return nil, err
+	}
+	l, ok := left.(bool)
+	if !ok {
+		return nil, fmt.Errorf("operator %s expects bools, got %v", e.op, left)
+	}
+
+	// short-circuit evaluation
+	if (e.op == "&&" && !l) || (e.op == "||" && l) {
+		return l, nil
+	}
+
+	right, err := e.right.eval(c)
+	if err != nil {
+		return nil, err
+	}
+	r, ok := right.(bool)
+	if !ok {
+		return nil, fmt.Errorf("operator %s expects bools, got %v", e.op, right)
+	}
+	return r, nil
+}
+
+type comparisonExpression struct {
+	op          string
+	left, right expression
+}
+
+func (e *comparisonExpression) eval(c *expressionContext) (interface{}, error) {
+	left, err := e.left.eval(c)
+	if err != nil {
+		return nil, err
+	}
+	right, err := e.right.eval(c)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.op {
+	case "==":
+		return reflect.DeepEqual(left, right), nil
+	case "!=":
+		return !reflect.DeepEqual(left, right), nil
+	}
+
+	// ordering is only defined between numbers or between strings
+	var cmp int
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare %v %s %v", left, e.op, right)
+		}
+		if l < r {
+			cmp = -1
+		} else if l > r {
+			cmp = 1
+		}
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare %v %s %v", left, e.op, right)
+		}
+		cmp = strings.Compare(l, r)
+	default:
+		return nil, fmt.Errorf("cannot compare %v %s %v", left, e.op, right)
+	}
+
+	switch e.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
@@ -0,0 +1,105 @@
+package httpclient
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestEvalBoolExpression(t *testing.T) {
+	c := &expressionContext{
+		code:    200,
+		headers: http.Header{"Content-Type": {"application/json"}},
+		body:    []byte(`{"status":"ready","count":3,"items":[{"id":"a"}]}`),
+	}
+
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{input: "true", expected: true},
+		{input: "code == 200", expected: true},
+		{input: "code != 200", expected: false},
+		{input: "code >= 200 && code <= 299", expected: true},
+		{input: "code < 200 || code > 299", expected: false},
+		// && binds tighter than ||
+		{input: "true || false && false", expected: true},
+		{input: "false && true || true", expected: true},
+		{input: "(true || false) && false", expected: false},
+		{input: "false && (true || true)", expected: false},
+		// ! binds tighter than the comparisons and the logical operators
+		{input: "!false && true", expected: true},
+		{input: "!true || true", expected: true},
+		{input: "!(true || true)", expected: false},
+		{input: "!!true", expected: true},
+		{input: "((code == 200))", expected: true},
+		// the right operand is not evaluated when the left one decides
+		{input: "false && code", expected: false},
+		{input: "true || code", expected: true},
+		{input: `header("content-type") == "application/json"`, expected: true},
+		{input: `jsonpath("$.status") == "ready" && jsonpath("$.count") > 2`, expected: true},
+		{input: `jsonpath("$.items[0].id") == 'a'`, expected: true},
+		{input: `contains(body, "ready") && !contains(body, "error")`, expected: true},
+		{input: `"b" > "a"`, expected: true},
+	}
+	for _, tt := range tests {
+		result, err := evalBoolExpression(tt.input, c)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tt.input, err)
+			continue
+		}
+		if result != tt.expected {
+			t.Errorf("%s is %t, expected %t", tt.input, result, tt.expected)
+		}
+	}
+}
+
+func TestParseExpressionErrors(t *testing.T) {
+	// malformed expressions are reported at plan time
+	for _, input := range []string{
+		"",
+		"(",
+		")",
+		"code ==",
+		"== 200",
+		"code = 200",
+		"code == 200)",
+		"(code == 200",
+		"code == 200 &&",
+		"|| true",
+		"&& true",
+		"!",
+		`"unterminated`,
+		"1.2.3",
+		"status == 200",
+		"code == 200 true",
+		"header(",
+		`header("a" "b")`,
+		`contains("a")`,
+		`jsonpath($.status)`,
+		"code # 200",
+	} {
+		if _, err := parseExpression(input); err == nil {
+			t.Errorf("%q: expected an error", input)
+		}
+	}
+}
+
+func TestEvalBoolExpressionErrors(t *testing.T) {
+	c := &expressionContext{code: 200, headers: http.Header{}, body: []byte("not json")}
+
+	// operands of the wrong type are reported when evaluated
+	for _, input := range []string{
+		"code",
+		"code && true",
+		"true && code",
+		"!code",
+		`code < "a"`,
+		"true < false",
+		`contains(code, "a")`,
+		`jsonpath("$.status") == "ready"`,
+	} {
+		if _, err := evalBoolExpression(input, c); err == nil {
+			t.Errorf("%q: expected an error", input)
+		}
+	}
+}
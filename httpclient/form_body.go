@@ -0,0 +1,102 @@
+package httpclient
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// formContentType is the media type of url encoded forms
+const formContentType = "application/x-www-form-urlencoded"
+
+// multipartPart is a part of a multipart/form-data body, its content is
+// read from File when set
+type multipartPart struct {
+	Name        string
+	Content     string
+	File        string
+	Filename    string
+	ContentType string
+}
+
+// encodeForm serializes the fields as an url encoded form, sorted by name
+func encodeForm(fields map[string]string) []byte {
+	values := make(neturl.Values, len(fields))
+	for name, value := range fields {
+		values.Set(name, value)
+	}
+	return []byte(values.Encode())
+}
+
+// encodeMultipart serializes the parts as a multipart/form-data body and
+// returns it with its content type, the boundary is derived from the parts
+// so that the same parts always give the same body
+func encodeMultipart(parts []multipartPart) ([]byte, string, error) {
+	contents := make([][]byte, len(parts))
+	boundary_hash := sha256.New()
+	for i, part := range parts {
+		contents[i] = []byte(part.Content)
+		if len(part.File) > 0 {
+			if len(part.Content) > 0 {
+				return nil, "", fmt.Errorf("request_body_multipart %s: content and file are mutually exclusive", part.Name)
+			}
+			content, err := os.ReadFile(part.File)
+			if err != nil {
+				return nil, "", fmt.Errorf("request_body_multipart %s: %w", part.Name, err)
+			}
+			contents[i] = content
+		}
+		fmt.Fprintf(boundary_hash, "%s\x00%s\x00%s\x00", part.Name, part.Filename, part.ContentType)
+		boundary_hash.Write(contents[i])
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.SetBoundary(hex.EncodeToString(boundary_hash.Sum(nil))[:40]); err != nil {
+		return nil, "", err
+	}
+
+	for i, part := range parts {
+		filename := part.Filename
+		if len(filename) == 0 && len(part.File) > 0 {
+			filename = filepath.Base(part.File)
+		}
+
+		header := make(textproto.MIMEHeader)
+		disposition := fmt.Sprintf(`form-data; name="%s"`, escapeQuotes(part.Name))
+		if len(filename) > 0 {
+			disposition += fmt.Sprintf(`; filename="%s"`, escapeQuotes(filename))
+		}
+		header.Set("Content-Disposition", disposition)
+		switch {
+		case len(part.ContentType) > 0:
+			header.Set("Content-Type", part.ContentType)
+		case len(filename) > 0:
+			header.Set("Content-Type", "application/octet-stream")
+		}
+
+		w, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := w.Write(contents[i]); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return body.Bytes(), writer.FormDataContentType(), nil
+}
+
+// escapeQuotes escapes the quoted strings of the Content-Disposition header
+func escapeQuotes(s string) string {
+	return strings.NewReplacer("\\", "\\\\", `"`, "\\\"").Replace(s)
+}
@@ -0,0 +1,213 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// headers and query parameters whose name contains one of these words are
+// replaced by a placeholder in exported har files, such as Authorization,
+// X-Vault-Token, Private-Token or access_token
+var sensitiveNameParts = []string{"auth", "token", "secret", "password", "passwd", "apikey", "api-key", "api_key",
+	"cookie", "session", "signature", "credential"}
+
+const redactedValue = "REDACTED"
+
+type harLog struct {
+	Log harContent `json:"log"`
+}
+
+type harContent struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	Cookies     []harNameValue `json:"cookies"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	Cookies     []harNameValue `json:"cookies"`
+	Content     harContentBody `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContentBody struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// harSanitizer redacts the exported exchange, the request body is
+// sensitive and never exported
+type harSanitizer struct {
+	// apiKeyHeader is the header carrying the api_key of the request
+	apiKeyHeader string
+	scrub        scrubber
+}
+
+// sensitive reports whether a header or query parameter holds a credential
+func (s harSanitizer) sensitive(name string) bool {
+	if len(s.apiKeyHeader) > 0 && strings.EqualFold(name, s.apiKeyHeader) {
+		return true
+	}
+	name = strings.ToLower(name)
+	for _, part := range sensitiveNameParts {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s harSanitizer) value(name string, value string) string {
+	if s.sensitive(name) {
+		return redactedValue
+	}
+	return s.scrub.apply(value)
+}
+
+// writeHAR exports the request and its response as a single entry har file,
+// credentials in headers and query parameters are redacted, the scrub
+// patterns are applied to the other values and to the response body
+func writeHAR(path string, body []byte, rsp *Response, sanitizer harSanitizer) error {
+	req := rsp.Request
+	elapsed := float64(rsp.Duration) / float64(time.Millisecond)
+
+	// the query is redacted in the url as well
+	query := req.URL.Query()
+	url := rsp.URL
+	if u, err := neturl.Parse(rsp.URL); err == nil {
+		for name, values := range query {
+			for i, value := range values {
+				values[i] = sanitizer.value(name, value)
+			}
+		}
+		u.RawQuery = query.Encode()
+		url = u.String()
+	}
+
+	entry := harEntry{
+		StartedDateTime: rsp.StartedAt.Format(time.RFC3339Nano),
+		Time:            elapsed,
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         sanitizer.scrub.apply(url),
+			HTTPVersion: rsp.Proto,
+			Headers:     harHeaders(req.Header, sanitizer),
+			QueryString: []harNameValue{},
+			Cookies:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    len(body),
+		},
+		Response: harResponse{
+			Status:      rsp.StatusCode,
+			StatusText:  strings.TrimSpace(strings.TrimPrefix(rsp.Status, strconv.Itoa(rsp.StatusCode))),
+			HTTPVersion: rsp.Proto,
+			Headers:     harHeaders(rsp.Headers, sanitizer),
+			Cookies:     []harNameValue{},
+			Content: harContentBody{
+				Size:     len(rsp.Body),
+				MimeType: rsp.Headers.Get("Content-Type"),
+				Text:     sanitizer.scrub.apply(string(rsp.Body)),
+			},
+			RedirectURL: rsp.Headers.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    len(rsp.Body),
+		},
+		Timings: harTimings{Wait: elapsed},
+	}
+
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range query[name] {
+			entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{Name: name, Value: value})
+		}
+	}
+	if len(body) > 0 {
+		entry.Request.PostData = &harPostData{MimeType: req.Header.Get("Content-Type"), Text: redactedValue}
+	}
+
+	har := harLog{Log: harContent{
+		Version: "1.2",
+		Creator: harCreator{Name: "terraform-provider-http-client", Version: "1.0"},
+		Entries: []harEntry{entry},
+	}}
+
+	data, err := json.MarshalIndent(har, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+func harHeaders(headers http.Header, sanitizer harSanitizer) []harNameValue {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := []harNameValue{}
+	for _, name := range names {
+		for _, value := range headers[name] {
+			list = append(list, harNameValue{Name: name, Value: sanitizer.value(name, value)})
+		}
+	}
+	return list
+}
@@ -0,0 +1,46 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHARRedaction(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"owner":"admin@example.com","page":2}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "request.har")
+	readRequest(t, map[string]interface{}{
+		"url":             server.URL + "/items?page=2&access_token=query-secret",
+		"request_method":  http.MethodPost,
+		"request_body":    `{"password":"body-secret"}`,
+		"request_headers": map[string]interface{}{"X-Vault-Token": "vault-secret", "Accept": "application/json"},
+		"api_key":         "key-secret",
+		"api_key_header":  "X-Custom",
+		"har_file":        path,
+		"scrub_patterns":  map[string]interface{}{`[\w.+-]+@[\w-]+\.[\w.]+`: "[email]"},
+	})
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	har := string(data)
+	for _, secret := range []string{"query-secret", "body-secret", "vault-secret", "key-secret", "admin@example.com"} {
+		if strings.Contains(har, secret) {
+			t.Errorf("har file contains %s", secret)
+		}
+	}
+	for _, kept := range []string{"page=2", "application/json", "[email]"} {
+		if !strings.Contains(har, kept) {
+			t.Errorf("har file does not contain %s", kept)
+		}
+	}
+}
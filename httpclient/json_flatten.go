@@ -0,0 +1,77 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// isJSONContentType reports whether the media type is application/json or
+// a +json structured syntax suffix, such as application/problem+json
+func isJSONContentType(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	return contentType == "application/json" || strings.HasSuffix(contentType, "+json")
+}
+
+// decodeJSON decodes a document whose values are looked up by path, numbers
+// are decoded as json.Number so that identifiers above 2^53 keep their
+// digits and large values are not written in exponent form
+func decodeJSON(body []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("invalid data after top-level value")
+	}
+	return doc, nil
+}
+
+// flattenJSON decodes the document into a flat map keyed by the dotted path
+// of each scalar value, such as `items.0.id`, since the plugin SDK has no
+// dynamic attribute type. Numbers keep their literal representation so that
+// large identifiers do not lose precision
+func flattenJSON(body []byte) (map[string]string, error) {
+	doc, err := decodeJSON(body)
+	if err != nil {
+		return nil, err
+	}
+
+	flat := make(map[string]string)
+	flattenJSONValue("", doc, flat)
+	return flat, nil
+}
+
+func flattenJSONValue(prefix string, value interface{}, flat map[string]string) {
+	key := func(name string) string {
+		if len(prefix) == 0 {
+			return name
+		}
+		return prefix + "." + name
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, item := range v {
+			flattenJSONValue(key(name), item, flat)
+		}
+	case []interface{}:
+		for i, item := range v {
+			flattenJSONValue(key(strconv.Itoa(i)), item, flat)
+		}
+	case nil:
+		flat[prefix] = ""
+	case json.Number:
+		flat[prefix] = v.String()
+	case bool:
+		flat[prefix] = strconv.FormatBool(v)
+	case string:
+		flat[prefix] = v
+	}
+}
@@ -0,0 +1,45 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// jsonPatchContentType is the media type of RFC 6902 documents
+const jsonPatchContentType = "application/json-patch+json"
+
+// jsonPatchOperations lists the RFC 6902 operations
+var jsonPatchOperations = []string{"add", "remove", "replace", "move", "copy", "test"}
+
+// jsonPatchOperation is one operation of a RFC 6902 document
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+	From  string          `json:"from,omitempty"`
+}
+
+// validate checks the members required by the operation are set
+func (o jsonPatchOperation) validate() error {
+	switch o.Op {
+	case "add", "replace", "test":
+		if o.Value == nil {
+			return fmt.Errorf("json_patch %s operation on %q requires a value", o.Op, o.Path)
+		}
+	case "move", "copy":
+		if len(o.From) == 0 {
+			return fmt.Errorf("json_patch %s operation on %q requires from", o.Op, o.Path)
+		}
+	}
+	return nil
+}
+
+// marshalJSONPatch serializes the operations as a RFC 6902 document
+func marshalJSONPatch(operations []jsonPatchOperation) ([]byte, error) {
+	for _, operation := range operations {
+		if err := operation.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(operations)
+}
@@ -0,0 +1,85 @@
+package httpclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonPathLookup evaluates a simple JSONPath expression against a decoded
+// json document. Only child (`$.a.b`, `$['a']`) and index (`$.a[0]`)
+// selectors are supported, the bool reports whether the path exists.
+func jsonPathLookup(doc interface{}, path string) (interface{}, bool, error) {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	current := doc
+	for _, step := range steps {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[step]
+			if !ok {
+				return nil, false, nil
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(step)
+			if err != nil {
+				return nil, false, nil
+			}
+			if index < 0 {
+				index += len(node)
+			}
+			if index < 0 || index >= len(node) {
+				return nil, false, nil
+			}
+			current = node[index]
+		default:
+			return nil, false, nil
+		}
+	}
+	return current, true, nil
+}
+
+// parseJSONPath splits a JSONPath expression into its successive keys
+func parseJSONPath(path string) ([]string, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("invalid json path %q: must start with $", path)
+	}
+
+	var steps []string
+	rest := path[1:]
+	for len(rest) > 0 {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid json path %q: empty key", path)
+			}
+			steps = append(steps, rest[:end])
+			rest = rest[end:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("invalid json path %q: missing ]", path)
+			}
+			key := strings.TrimSpace(rest[1:end])
+			if len(key) >= 2 && (key[0] == '\'' || key[0] == '"') && key[len(key)-1] == key[0] {
+				key = key[1 : len(key)-1]
+			} else if _, err := strconv.Atoi(key); err != nil {
+				return nil, fmt.Errorf("invalid json path %q: unsupported selector [%s]", path, key)
+			}
+			steps = append(steps, key)
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid json path %q: unexpected %q", path, rest[0])
+		}
+	}
+	return steps, nil
+}
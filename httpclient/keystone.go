@@ -0,0 +1,122 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// authKeystone selects the OpenStack Keystone v3 authentication
+const authKeystone = "keystone"
+
+// KeystoneAuth are the settings of the Keystone v3 authentication, the user
+// name and password are the ones of the request
+type KeystoneAuth struct {
+	// AuthURL is the identity endpoint, such as https://keystone:5000/v3
+	AuthURL        string
+	UserDomainName string
+
+	// project scope, by id or by name within a domain
+	ProjectID         string
+	ProjectName       string
+	ProjectDomainName string
+
+	// application credentials replace the user name and password
+	ApplicationCredentialID     string
+	ApplicationCredentialSecret string
+}
+
+// keystoneToken returns a token for the request credentials, tokens are
+// cached by the provider until they are about to expire
+func keystoneToken(ctx context.Context, config *providerConfig, rc *RequestConfig) (string, error) {
+	keystone := rc.Keystone
+	if keystone == nil || len(keystone.AuthURL) == 0 {
+		return "", newRequestError(errorCodeConfig, "keystone authentication: the keystone block is required with auth_type keystone")
+	}
+
+	key := tokenCacheKey(authKeystone, keystone.AuthURL, rc.Username, rc.Password, keystone.UserDomainName,
+		keystone.ProjectID, keystone.ProjectName, keystone.ProjectDomainName,
+		keystone.ApplicationCredentialID, keystone.ApplicationCredentialSecret)
+	if token, ok := config.tokenCache.get(key, time.Now()); ok {
+		return token, nil
+	}
+
+	body, err := json.Marshal(keystoneAuthBody(rc))
+	if err != nil {
+		return "", err
+	}
+
+	// the credentials are in the body
+	token_url := strings.TrimSuffix(keystone.AuthURL, "/") + "/auth/tokens"
+	token_rc := authEndpointConfig(rc, token_url, http.MethodPost, map[string]string{"Content-Type": "application/json"}, body)
+	token_rc.Username = ""
+	token_rc.Password = ""
+
+	rsp, err := executeRequest(ctx, config, token_rc)
+	if err != nil {
+		return "", newRequestError(errorCode(err), "keystone authentication: %s", err)
+	}
+	if rsp.StatusCode != http.StatusCreated {
+		return "", newRequestError(errorCodeStatus, "keystone authentication: identity endpoint returned status %d", rsp.StatusCode)
+	}
+
+	token := rsp.Headers.Get("X-Subject-Token")
+	if len(token) == 0 {
+		return "", newRequestError(errorCodeBodyDecode, "keystone authentication: no X-Subject-Token header in identity endpoint response")
+	}
+
+	var document struct {
+		Token struct {
+			ExpiresAt time.Time `json:"expires_at"`
+		} `json:"token"`
+	}
+	if err := json.Unmarshal(rsp.Body, &document); err != nil {
+		return "", newRequestError(errorCodeBodyDecode, "keystone authentication: invalid token response: %s", err)
+	}
+	config.tokenCache.set(key, token, document.Token.ExpiresAt)
+
+	return token, nil
+}
+
+// keystoneAuthBody builds the body of the token request
+func keystoneAuthBody(rc *RequestConfig) map[string]interface{} {
+	keystone := rc.Keystone
+
+	// application credentials are already scoped
+	if len(keystone.ApplicationCredentialID) > 0 {
+		return map[string]interface{}{"auth": map[string]interface{}{
+			"identity": map[string]interface{}{
+				"methods": []string{"application_credential"},
+				"application_credential": map[string]interface{}{
+					"id":     keystone.ApplicationCredentialID,
+					"secret": keystone.ApplicationCredentialSecret,
+				},
+			},
+		}}
+	}
+
+	auth := map[string]interface{}{
+		"identity": map[string]interface{}{
+			"methods": []string{"password"},
+			"password": map[string]interface{}{
+				"user": map[string]interface{}{
+					"name":     rc.Username,
+					"password": rc.Password,
+					"domain":   map[string]interface{}{"name": keystone.UserDomainName},
+				},
+			},
+		},
+	}
+	switch {
+	case len(keystone.ProjectID) > 0:
+		auth["scope"] = map[string]interface{}{"project": map[string]interface{}{"id": keystone.ProjectID}}
+	case len(keystone.ProjectName) > 0:
+		auth["scope"] = map[string]interface{}{"project": map[string]interface{}{
+			"name":   keystone.ProjectName,
+			"domain": map[string]interface{}{"name": keystone.ProjectDomainName},
+		}}
+	}
+	return map[string]interface{}{"auth": auth}
+}
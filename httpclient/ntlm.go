@@ -0,0 +1,253 @@
+package httpclient
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/md4"
+)
+
+// authNTLM selects the NTLMv2 authentication of Windows integrated endpoints
+const authNTLM = "ntlm"
+
+// NTLMAuth are the settings of the NTLM authentication, the user name and
+// password are the ones of the request
+type NTLMAuth struct {
+	Domain      string
+	Workstation string
+}
+
+// NTLM message flags (MS-NLMP 2.2.2.5)
+const (
+	ntlmNegotiateUnicode         = 0x00000001
+	ntlmRequestTarget            = 0x00000004
+	ntlmNegotiateNTLM            = 0x00000200
+	ntlmNegotiateAlwaysSign      = 0x00008000
+	ntlmNegotiateExtendedSession = 0x00080000
+	ntlmNegotiateTargetInfo      = 0x00800000
+	ntlmNegotiateVersion         = 0x02000000
+	ntlmNegotiate128             = 0x20000000
+	ntlmNegotiateKeyExch         = 0x40000000
+	ntlmNegotiate56              = 0x80000000
+)
+
+// ntlmSignature starts every NTLM message
+var ntlmSignature = []byte("NTLMSSP\x00")
+
+// ntlmTransport runs the NTLM handshake on the connection of the request:
+// the negotiate message, the challenge of the 401 response, then the
+// request with the authenticate message. NTLM authenticates connections,
+// so the transport must keep them alive and speak HTTP/1.1.
+type ntlmTransport struct {
+	base     http.RoundTripper
+	username string
+	password string
+	ntlm     NTLMAuth
+}
+
+func (t *ntlmTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	negotiate_req, err := cloneRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	negotiate_req.Header.Set("Authorization", "NTLM "+base64.StdEncoding.EncodeToString(ntlmNegotiateMessage()))
+	rsp, err := t.base.RoundTrip(negotiate_req)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := ntlmChallengeHeader(rsp.Header)
+	if rsp.StatusCode != http.StatusUnauthorized || challenge == nil {
+		return rsp, nil
+	}
+	// drain the body so that the connection is reused
+	io.Copy(io.Discard, rsp.Body)
+	rsp.Body.Close()
+
+	message, err := ntlmAuthenticateMessage(challenge, t.username, t.password, t.ntlm, time.Now())
+	if err != nil {
+		return nil, newRequestError(errorCodeBodyDecode, "ntlm authentication: %s", err)
+	}
+	authenticate_req, err := cloneRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	authenticate_req.Header.Set("Authorization", "NTLM "+base64.StdEncoding.EncodeToString(message))
+	return t.base.RoundTrip(authenticate_req)
+}
+
+// cloneRequest returns a copy of the request with a fresh body
+func cloneRequest(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		clone.Body = body
+	}
+	return clone, nil
+}
+
+// ntlmChallengeHeader returns the decoded challenge of a WWW-Authenticate
+// NTLM header, nil when there is none
+func ntlmChallengeHeader(headers http.Header) []byte {
+	for _, value := range headers.Values("WWW-Authenticate") {
+		scheme, token, _ := strings.Cut(strings.TrimSpace(value), " ")
+		if !strings.EqualFold(scheme, "NTLM") || len(token) == 0 {
+			continue
+		}
+		if challenge, err := base64.StdEncoding.DecodeString(strings.TrimSpace(token)); err == nil {
+			return challenge
+		}
+	}
+	return nil
+}
+
+// ntlmNegotiateMessage is the first message, without domain nor workstation
+func ntlmNegotiateMessage() []byte {
+	message := make([]byte, 32)
+	copy(message, ntlmSignature)
+	binary.LittleEndian.PutUint32(message[8:], 1)
+	binary.LittleEndian.PutUint32(message[12:], ntlmNegotiateUnicode|ntlmRequestTarget|ntlmNegotiateNTLM|
+		ntlmNegotiateAlwaysSign|ntlmNegotiateExtendedSession|ntlmNegotiateTargetInfo|ntlmNegotiate128|ntlmNegotiate56)
+	return message
+}
+
+// ntlmAuthenticateMessage answers the challenge with a NTLMv2 response
+func ntlmAuthenticateMessage(challenge []byte, username string, password string, settings NTLMAuth, now time.Time) ([]byte, error) {
+	if len(challenge) < 48 || !bytes.Equal(challenge[:8], ntlmSignature) || binary.LittleEndian.Uint32(challenge[8:]) != 2 {
+		return nil, errors.New("invalid challenge message")
+	}
+	flags := binary.LittleEndian.Uint32(challenge[20:])
+	server_challenge := challenge[24:32]
+	target_info, err := ntlmSecurityBuffer(challenge, 40)
+	if err != nil {
+		return nil, err
+	}
+
+	// DOMAIN\user overrides the domain setting
+	domain := settings.Domain
+	if d, u, ok := strings.Cut(username, `\`); ok {
+		domain, username = d, u
+	}
+
+	// the server time is used when the challenge has one
+	timestamp := make([]byte, 8)
+	server_timestamp := ntlmTargetInfoValue(target_info, 7)
+	if len(server_timestamp) == 8 {
+		copy(timestamp, server_timestamp)
+	} else {
+		// 100 nanoseconds since January 1, 1601
+		binary.LittleEndian.PutUint64(timestamp, uint64(now.UnixNano()/100+116444736000000000))
+	}
+
+	client_challenge := make([]byte, 8)
+	if _, err := rand.Read(client_challenge); err != nil {
+		return nil, err
+	}
+
+	// the lm response is empty when the server sends its time
+	response_key := ntlmV2Key(username, password, domain)
+	nt_response, lm_response := ntlmV2Response(response_key, server_challenge, client_challenge, timestamp, target_info)
+	if len(server_timestamp) == 8 {
+		lm_response = make([]byte, 24)
+	}
+
+	// header of 64 bytes followed by the payload of the security buffers
+	payloads := [][]byte{lm_response, nt_response, utf16le(domain), utf16le(username), utf16le(settings.Workstation), nil}
+	message := make([]byte, 64)
+	copy(message, ntlmSignature)
+	binary.LittleEndian.PutUint32(message[8:], 3)
+	offset := len(message)
+	for i, payload := range payloads {
+		field := message[12+8*i:]
+		binary.LittleEndian.PutUint16(field[0:], uint16(len(payload)))
+		binary.LittleEndian.PutUint16(field[2:], uint16(len(payload)))
+		binary.LittleEndian.PutUint32(field[4:], uint32(offset))
+		offset += len(payload)
+	}
+	// no session key nor version are sent
+	binary.LittleEndian.PutUint32(message[60:], flags&^(ntlmNegotiateKeyExch|ntlmNegotiateVersion))
+	for _, payload := range payloads {
+		message = append(message, payload...)
+	}
+	return message, nil
+}
+
+// ntlmV2Key is the NTOWFv2 key of the credentials
+func ntlmV2Key(username string, password string, domain string) []byte {
+	nt_hash := md4.New()
+	nt_hash.Write(utf16le(password))
+	return hmacMD5(nt_hash.Sum(nil), utf16le(strings.ToUpper(username)+domain))
+}
+
+// ntlmV2Response returns the NTLMv2 and LMv2 responses (MS-NLMP 3.3.2), the
+// NTLMv2 one starts with the NTProofStr
+func ntlmV2Response(response_key []byte, server_challenge []byte, client_challenge []byte, timestamp []byte, target_info []byte) ([]byte, []byte) {
+	var blob bytes.Buffer
+	blob.Write([]byte{1, 1, 0, 0, 0, 0, 0, 0})
+	blob.Write(timestamp)
+	blob.Write(client_challenge)
+	blob.Write([]byte{0, 0, 0, 0})
+	blob.Write(target_info)
+	blob.Write([]byte{0, 0, 0, 0})
+
+	nt_proof := hmacMD5(response_key, append(append([]byte{}, server_challenge...), blob.Bytes()...))
+	nt_response := append(nt_proof, blob.Bytes()...)
+	lm_response := append(hmacMD5(response_key, append(append([]byte{}, server_challenge...), client_challenge...)), client_challenge...)
+	return nt_response, lm_response
+}
+
+// ntlmSecurityBuffer returns the payload of the security buffer at offset
+func ntlmSecurityBuffer(message []byte, offset int) ([]byte, error) {
+	length := int(binary.LittleEndian.Uint16(message[offset:]))
+	start := int(binary.LittleEndian.Uint32(message[offset+4:]))
+	if start+length > len(message) {
+		return nil, errors.New("security buffer out of the message")
+	}
+	return message[start : start+length], nil
+}
+
+// ntlmTargetInfoValue returns the value of an AV_PAIR of the target info
+func ntlmTargetInfoValue(target_info []byte, id uint16) []byte {
+	for len(target_info) >= 4 {
+		av_id := binary.LittleEndian.Uint16(target_info)
+		length := int(binary.LittleEndian.Uint16(target_info[2:]))
+		if av_id == 0 || 4+length > len(target_info) {
+			return nil
+		}
+		if av_id == id {
+			return target_info[4 : 4+length]
+		}
+		target_info = target_info[4+length:]
+	}
+	return nil
+}
+
+// utf16le encodes s as UTF-16 little endian
+func utf16le(s string) []byte {
+	codes := utf16.Encode([]rune(s))
+	b := make([]byte, 2*len(codes))
+	for i, code := range codes {
+		binary.LittleEndian.PutUint16(b[2*i:], code)
+	}
+	return b
+}
+
+// hmacMD5 is the keyed hash of the NTLMv2 computations
+func hmacMD5(key []byte, data []byte) []byte {
+	mac := hmac.New(md5.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,183 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+// reference values of MS-NLMP 4.2.1 and 4.2.4
+var (
+	ntlmTestServerChallenge = mustDecodeHex("0123456789abcdef")
+	ntlmTestClientChallenge = mustDecodeHex("aaaaaaaaaaaaaaaa")
+	// MsvAvNbDomainName "Domain", MsvAvNbComputerName "Server", MsvAvEOL
+	ntlmTestTargetInfo = mustDecodeHex("02000c0044006f006d00610069006e0001000c0053006500720076006500720000000000")
+)
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestNTLMV2Key(t *testing.T) {
+	tests := []struct {
+		username string
+		password string
+		domain   string
+		key      string
+	}{
+		{username: "User", password: "Password", domain: "Domain", key: "0c868a403bfd7a93a3001ef22ef02e3f"},
+		// the user name is case insensitive, the domain is not
+		{username: "USER", password: "Password", domain: "Domain", key: "0c868a403bfd7a93a3001ef22ef02e3f"},
+		{username: "user", password: "Password", domain: "Domain", key: "0c868a403bfd7a93a3001ef22ef02e3f"},
+	}
+	for _, tt := range tests {
+		if key := hex.EncodeToString(ntlmV2Key(tt.username, tt.password, tt.domain)); key != tt.key {
+			t.Errorf("NTOWFv2(%s, %s, %s) is %s, expected %s", tt.username, tt.password, tt.domain, key, tt.key)
+		}
+	}
+}
+
+func TestNTLMV2Response(t *testing.T) {
+	tests := []struct {
+		name     string
+		response func(nt []byte, lm []byte) []byte
+		expected string
+	}{
+		{
+			name:     "NTProofStr",
+			response: func(nt []byte, lm []byte) []byte { return nt[:16] },
+			expected: "68cd0ab851e51c96aabc927bebef6a1c",
+		},
+		{
+			name:     "LMv2",
+			response: func(nt []byte, lm []byte) []byte { return lm },
+			expected: "86c35097ac9cec102554764a57cccc19aaaaaaaaaaaaaaaa",
+		},
+		{
+			// version, reserved, time, client challenge, reserved, target
+			// info, reserved
+			name:     "temp",
+			response: func(nt []byte, lm []byte) []byte { return nt[16:] },
+			expected: "0101000000000000" + "0000000000000000" + "aaaaaaaaaaaaaaaa" + "00000000" +
+				hex.EncodeToString(ntlmTestTargetInfo) + "00000000",
+		},
+	}
+
+	key := ntlmV2Key("User", "Password", "Domain")
+	nt, lm := ntlmV2Response(key, ntlmTestServerChallenge, ntlmTestClientChallenge, make([]byte, 8), ntlmTestTargetInfo)
+	for _, tt := range tests {
+		if value := hex.EncodeToString(tt.response(nt, lm)); value != tt.expected {
+			t.Errorf("%s is %s, expected %s", tt.name, value, tt.expected)
+		}
+	}
+}
+
+// ntlmTestChallenge builds a challenge message with the target info
+func ntlmTestChallenge(flags uint32, target_info []byte) []byte {
+	message := make([]byte, 56)
+	copy(message, ntlmSignature)
+	binary.LittleEndian.PutUint32(message[8:], 2)
+	binary.LittleEndian.PutUint32(message[16:], 56)
+	binary.LittleEndian.PutUint32(message[20:], flags)
+	copy(message[24:], ntlmTestServerChallenge)
+	binary.LittleEndian.PutUint16(message[40:], uint16(len(target_info)))
+	binary.LittleEndian.PutUint16(message[42:], uint16(len(target_info)))
+	binary.LittleEndian.PutUint32(message[44:], 56)
+	return append(message, target_info...)
+}
+
+func TestNTLMAuthenticateMessage(t *testing.T) {
+	// MsvAvTimestamp followed by the target info of the reference
+	server_time := mustDecodeHex("07000800" + "0090d336b734c301")
+	with_time := append(append([]byte{}, server_time...), ntlmTestTargetInfo...)
+
+	tests := []struct {
+		name        string
+		username    string
+		settings    NTLMAuth
+		target_info []byte
+		domain      string
+		user        string
+		zero_lm     bool
+	}{
+		{name: "domain setting", username: "User", settings: NTLMAuth{Domain: "Domain", Workstation: "COMPUTER"}, target_info: ntlmTestTargetInfo, domain: "Domain", user: "User"},
+		{name: "domain in user name", username: `CORP\User`, settings: NTLMAuth{Domain: "Domain"}, target_info: ntlmTestTargetInfo, domain: "CORP", user: "User"},
+		{name: "server time", username: "User", settings: NTLMAuth{Domain: "Domain"}, target_info: with_time, domain: "Domain", user: "User", zero_lm: true},
+	}
+
+	flags := uint32(ntlmNegotiateUnicode | ntlmNegotiateNTLM | ntlmNegotiateKeyExch | ntlmNegotiateVersion)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, err := ntlmAuthenticateMessage(ntlmTestChallenge(flags, tt.target_info), tt.username, "Password", tt.settings, time.Unix(0, 0))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !bytes.Equal(message[:8], ntlmSignature) || binary.LittleEndian.Uint32(message[8:]) != 3 {
+				t.Fatalf("not an authenticate message: %x", message[:12])
+			}
+
+			// security buffers in the order of MS-NLMP 2.2.1.3, the payload
+			// starts after the 64 bytes header
+			buffers := make([][]byte, 6)
+			offset := 64
+			for i := range buffers {
+				if start := int(binary.LittleEndian.Uint32(message[12+8*i+4:])); start != offset {
+					t.Errorf("security buffer %d starts at %d, expected %d", i, start, offset)
+				}
+				buffers[i], err = ntlmSecurityBuffer(message, 12+8*i)
+				if err != nil {
+					t.Fatalf("security buffer %d: %s", i, err)
+				}
+				offset += len(buffers[i])
+			}
+			if offset != len(message) {
+				t.Errorf("message is %d bytes, expected %d", len(message), offset)
+			}
+
+			lm, nt := buffers[0], buffers[1]
+			if len(lm) != 24 || bytes.Equal(lm, make([]byte, 24)) != tt.zero_lm {
+				t.Errorf("unexpected lm response %x", lm)
+			}
+			if len(nt) != 16+28+len(tt.target_info)+4 || !bytes.Contains(nt, tt.target_info) {
+				t.Errorf("unexpected nt response %x", nt)
+			}
+			if !bytes.Equal(buffers[2], utf16le(tt.domain)) {
+				t.Errorf("domain is %x, expected %s", buffers[2], tt.domain)
+			}
+			if !bytes.Equal(buffers[3], utf16le(tt.user)) {
+				t.Errorf("user is %x, expected %s", buffers[3], tt.user)
+			}
+			if !bytes.Equal(buffers[4], utf16le(tt.settings.Workstation)) {
+				t.Errorf("workstation is %x, expected %s", buffers[4], tt.settings.Workstation)
+			}
+			if len(buffers[5]) != 0 {
+				t.Errorf("unexpected session key %x", buffers[5])
+			}
+
+			// the proof is computed with the key of the credentials
+			key := ntlmV2Key(tt.user, "Password", tt.domain)
+			proof := hmacMD5(key, append(append([]byte{}, ntlmTestServerChallenge...), nt[16:]...))
+			if !bytes.Equal(nt[:16], proof) {
+				t.Errorf("NTProofStr is %x, expected %x", nt[:16], proof)
+			}
+
+			if negotiated := binary.LittleEndian.Uint32(message[60:]); negotiated != flags&^(ntlmNegotiateKeyExch|ntlmNegotiateVersion) {
+				t.Errorf("flags are %08x, expected no key exchange nor version", negotiated)
+			}
+		})
+	}
+}
+
+func TestNTLMAuthenticateMessageInvalidChallenge(t *testing.T) {
+	for _, challenge := range [][]byte{nil, []byte("NTLMSSP\x00"), ntlmNegotiateMessage()} {
+		if _, err := ntlmAuthenticateMessage(challenge, "User", "Password", NTLMAuth{}, time.Now()); err == nil {
+			t.Errorf("expected an error for challenge %x", challenge)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+)
+
+// client authentication methods of the oauth2 block
+const (
+	oauth2AuthBasic = "basic"
+	oauth2AuthPost  = "post"
+)
+
+// oauth2DefaultLifetime applies when the token response has no expires_in
+const oauth2DefaultLifetime = time.Hour
+
+// OAuth2 are the settings of the OAuth2 client credentials grant
+type OAuth2 struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	Audience     string
+	// AuthStyle sends the client credentials with basic authentication or
+	// in the form body
+	AuthStyle string
+}
+
+// oauth2Token returns an access token from the token endpoint, tokens are
+// cached by the provider until they are about to expire
+func oauth2Token(ctx context.Context, config *providerConfig, rc *RequestConfig) (string, error) {
+	oauth2 := rc.OAuth2
+	key := tokenCacheKey("oauth2", oauth2.TokenURL, oauth2.ClientID, oauth2.ClientSecret,
+		strings.Join(oauth2.Scopes, " "), oauth2.Audience)
+	if token, ok := config.tokenCache.get(key, time.Now()); ok {
+		return token, nil
+	}
+
+	form := neturl.Values{"grant_type": {"client_credentials"}}
+	if len(oauth2.Scopes) > 0 {
+		form.Set("scope", strings.Join(oauth2.Scopes, " "))
+	}
+	if len(oauth2.Audience) > 0 {
+		form.Set("audience", oauth2.Audience)
+	}
+
+	// the token endpoint is called with the same tls settings
+	headers := map[string]string{"Content-Type": "application/x-www-form-urlencoded", "Accept": "application/json"}
+	token_rc := authEndpointConfig(rc, oauth2.TokenURL, http.MethodPost, headers, nil)
+	if oauth2.AuthStyle == oauth2AuthPost {
+		form.Set("client_id", oauth2.ClientID)
+		form.Set("client_secret", oauth2.ClientSecret)
+		token_rc.Username = ""
+		token_rc.Password = ""
+	} else {
+		token_rc.Username = neturl.QueryEscape(oauth2.ClientID)
+		token_rc.Password = neturl.QueryEscape(oauth2.ClientSecret)
+	}
+	token_rc.Body = []byte(form.Encode())
+
+	started_at := time.Now()
+	rsp, err := executeRequest(ctx, config, token_rc)
+	if err != nil {
+		return "", newRequestError(errorCode(err), "oauth2: %s", err)
+	}
+	if rsp.StatusCode != http.StatusOK {
+		return "", newRequestError(errorCodeStatus, "oauth2: token endpoint returned status %d", rsp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(rsp.Body, &token); err != nil {
+		return "", newRequestError(errorCodeBodyDecode, "oauth2: invalid token response: %s", err)
+	}
+	if len(token.AccessToken) == 0 {
+		return "", newRequestError(errorCodeBodyDecode, "oauth2: no access_token in token endpoint response")
+	}
+
+	lifetime := oauth2DefaultLifetime
+	if token.ExpiresIn > 0 {
+		lifetime = time.Duration(token.ExpiresIn) * time.Second
+	}
+	config.tokenCache.set(key, token.AccessToken, started_at.Add(lifetime))
+
+	return token.AccessToken, nil
+}
@@ -0,0 +1,77 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OffloadSettings store the bodies larger than Threshold outside of the
+// state, at a local path or at the http(s) url of a S3 compatible object
+type OffloadSettings struct {
+	Destination string
+	Threshold   int64
+	// S3Region signs the upload to an http(s) destination with AWS
+	// Signature Version 4 and the default credentials, none when empty
+	S3Region string
+}
+
+// offloadBody stores the body at the destination and returns its location
+func offloadBody(ctx context.Context, config *providerConfig, rc *RequestConfig, settings *OffloadSettings, body []byte) (string, error) {
+	destination := settings.Destination
+	if !strings.HasPrefix(destination, "http://") && !strings.HasPrefix(destination, "https://") {
+		path := strings.TrimPrefix(destination, "file://")
+		if err := writeFileAtomic(path, body); err != nil {
+			return "", newRequestError(errorCodeConfig, "offload_body_to: %s", err)
+		}
+		return path, nil
+	}
+
+	// upload the object with the tls settings of the request
+	upload_rc := &RequestConfig{
+		URL:         destination,
+		Method:      http.MethodPut,
+		Headers:     map[string]string{"Content-Type": "application/octet-stream"},
+		Body:        body,
+		Insecure:    rc.Insecure,
+		Timeout:     rc.Timeout,
+		SuccessWhen: defaultHealthyWhen,
+	}
+	if len(settings.S3Region) > 0 {
+		upload_rc.AWSSigV4 = &AWSSigV4{Region: settings.S3Region, Service: "s3", UseDefaultCredentials: true}
+	}
+	if _, err := ExecuteRequest(ctx, config, upload_rc); err != nil {
+		return "", newRequestError(errorCode(err), "offload_body_to: %s", err)
+	}
+
+	// credentials of the url are not written to the state
+	location, _, _, err := splitURLCredentials(destination)
+	if err != nil {
+		return "", newRequestError(errorCodeConfig, "offload_body_to: %s", err)
+	}
+	return location, nil
+}
+
+// writeFileAtomic writes the file next to the destination, then renames it
+// once complete
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := tmp.Write(data); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
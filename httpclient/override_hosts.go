@@ -0,0 +1,108 @@
+package httpclient
+
+import (
+	"fmt"
+	"net"
+	neturl "net/url"
+	"sort"
+	"strings"
+)
+
+// overrideHostsEnv points the requests to other endpoints, such as the stub
+// servers of a terraform test suite, without changing the configuration
+const overrideHostsEnv = "TF_HTTPCLIENT_OVERRIDE_HOSTS"
+
+// parseOverrideHosts parses a comma separated list of host=url entries, the
+// host is a host name or a host:port and the url has a scheme and a host only,
+// for example `api.example.com=http://127.0.0.1:8080`
+func parseOverrideHosts(value string) (map[string]*neturl.URL, error) {
+	overrides := make(map[string]*neturl.URL)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		host, target, ok := strings.Cut(entry, "=")
+		host = strings.ToLower(strings.TrimSpace(host))
+		if !ok || len(host) == 0 {
+			return nil, fmt.Errorf("%s: invalid entry %q, expected host=url", overrideHostsEnv, entry)
+		}
+		u, err := neturl.Parse(strings.TrimSpace(target))
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid url for %s: %s", overrideHostsEnv, host, err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 || strings.Trim(u.Path, "/") != "" {
+			return nil, fmt.Errorf("%s: the url of %s must be http(s)://host[:port]", overrideHostsEnv, host)
+		}
+		overrides[host] = u
+	}
+	return overrides, nil
+}
+
+// overrideURL returns the url with the scheme and host of its override, a
+// host:port entry takes precedence over a host one
+func overrideURL(rawURL string, overrides map[string]*neturl.URL) string {
+	if len(overrides) == 0 {
+		return rawURL
+	}
+	u, err := neturl.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	target, ok := lookupOverride(overrides, u.Host, u.Hostname())
+	if !ok {
+		return rawURL
+	}
+	u.Scheme = target.Scheme
+	u.Host = target.Host
+	return u.String()
+}
+
+// overrideAddress returns the host:port of the override of a host:port
+// address, for the connections opened without url such as the banners
+func overrideAddress(address string, overrides map[string]*neturl.URL) string {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+	target, ok := lookupOverride(overrides, address, host)
+	if !ok {
+		return address
+	}
+	port := target.Port()
+	if len(port) == 0 {
+		port = "80"
+		if target.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(target.Hostname(), port)
+}
+
+// overrideName returns the host name of the override of a name, for the
+// dns lookups, the name itself when it is not overridden
+func overrideName(name string, overrides map[string]*neturl.URL) string {
+	if target, ok := lookupOverride(overrides, name, name); ok {
+		return target.Hostname()
+	}
+	return name
+}
+
+// lookupOverride returns the override of a host:port, or of the host name
+func lookupOverride(overrides map[string]*neturl.URL, host_port string, hostname string) (*neturl.URL, bool) {
+	target, ok := overrides[strings.ToLower(host_port)]
+	if !ok {
+		target, ok = overrides[strings.ToLower(hostname)]
+	}
+	return target, ok
+}
+
+// overrideHostsSummary lists the overrides, for the provider warning
+func overrideHostsSummary(overrides map[string]*neturl.URL) string {
+	entries := make([]string, 0, len(overrides))
+	for host, target := range overrides {
+		entries = append(entries, host+" => "+target.String())
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ", ")
+}
@@ -0,0 +1,71 @@
+package httpclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestOverrideHostsOutsideRequests(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte("220 stub ESMTP\r\n"))
+			conn.Close()
+		}
+	}()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("stub"))
+	}))
+	defer server.Close()
+
+	config := newTestConfig()
+	config.overrideHosts, err = parseOverrideHosts("smtp.example.invalid=http://" + listener.Addr().String() +
+		",_api._tcp.example.invalid=" + server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the banner connects to the stub
+	d := schema.TestResourceDataRaw(t, dataSourceBanner().Schema, map[string]interface{}{"address": "smtp.example.invalid:25"})
+	if diags := dataSourceBannerRead(context.Background(), d, config); diags.HasError() {
+		t.Fatalf("unexpected errors: %v", diags)
+	}
+	if banner := d.Get("banner").(string); banner != "220 stub ESMTP\r\n" {
+		t.Errorf("banner is %q", banner)
+	}
+
+	// the dns lookup is made for the host of the stub
+	d = schema.TestResourceDataRaw(t, dataSourceDNSCheck().Schema, map[string]interface{}{"name": "smtp.example.invalid"})
+	if diags := dataSourceDNSCheckRead(context.Background(), d, config); diags.HasError() {
+		t.Fatalf("unexpected errors: %v", diags)
+	}
+	if records := d.Get("records").([]interface{}); len(records) != 1 || records[0] != "127.0.0.1" {
+		t.Errorf("records are %v", records)
+	}
+
+	// the SRV record is not resolved
+	rsp, err := ExecuteRequest(context.Background(), config, &RequestConfig{
+		URL:          "http://_api._tcp.example.invalid/",
+		Method:       http.MethodGet,
+		UseSRVLookup: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(rsp.Body) != "stub" {
+		t.Errorf("body is %q", rsp.Body)
+	}
+}
@@ -0,0 +1,83 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+)
+
+// phases of a request, in order
+const (
+	phaseDNS     = "dns lookup"
+	phaseConnect = "connect"
+	phaseTLS     = "tls handshake"
+	phaseSend    = "sending request"
+	phaseWait    = "waiting for response headers"
+	phaseBody    = "reading body"
+)
+
+// requestPhases records the phases of a request from httptrace events, to
+// tell which one exceeded the timeout
+type requestPhases struct {
+	mu        sync.Mutex
+	current   string
+	startedAt time.Time
+	completed []string
+}
+
+// enter starts a phase, ending the current one
+func (p *requestPhases) enter(phase string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	if len(p.current) > 0 && p.current != phase {
+		p.completed = append(p.completed, fmt.Sprintf("%s %s", p.current, now.Sub(p.startedAt).Round(time.Millisecond)))
+	}
+	if p.current != phase {
+		p.current = phase
+		p.startedAt = now
+	}
+}
+
+// trace hooks the phases into the client trace
+func (p *requestPhases) trace(trace *httptrace.ClientTrace) {
+	trace.DNSStart = func(httptrace.DNSStartInfo) { p.enter(phaseDNS) }
+	trace.ConnectStart = func(string, string) { p.enter(phaseConnect) }
+	trace.TLSHandshakeStart = func() { p.enter(phaseTLS) }
+	trace.TLSHandshakeDone = func(tls.ConnectionState, error) { p.enter(phaseSend) }
+	trace.WroteRequest = func(httptrace.WroteRequestInfo) { p.enter(phaseWait) }
+
+	got_conn := trace.GotConn
+	trace.GotConn = func(info httptrace.GotConnInfo) {
+		p.enter(phaseSend)
+		if got_conn != nil {
+			got_conn(info)
+		}
+	}
+}
+
+// timeoutError names the phase a timeout happened in, other errors are
+// returned as is
+func (p *requestPhases) timeoutError(err error) error {
+	if err == nil || errorCode(err) != errorCodeTimeout {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.current) == 0 {
+		return err
+	}
+	detail := ""
+	if len(p.completed) > 0 {
+		detail = fmt.Sprintf(" (after %s)", strings.Join(p.completed, ", "))
+	}
+	return &RequestError{
+		Code: errorCodeTimeout,
+		Err:  fmt.Errorf("timeout while %s, %s elapsed in this phase%s: %w", p.current, time.Since(p.startedAt).Round(time.Millisecond), detail, err),
+	}
+}
@@ -0,0 +1,41 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// pipeBody streams the body to the stdin of a program and returns its
+// stdout, the program is started directly without a shell
+func pipeBody(ctx context.Context, argv []string, body []byte, timeout time.Duration) ([]byte, error) {
+	return runCommand(ctx, "pipe_response_to", argv, body, timeout)
+}
+
+// runCommand starts the program without a shell and returns its stdout, the
+// attribute configuring the command prefixes the errors
+func runCommand(ctx context.Context, attribute string, argv []string, stdin []byte, timeout time.Duration) ([]byte, error) {
+	if len(argv) == 0 {
+		return nil, fmt.Errorf("%s: missing command", attribute)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, newRequestError(errorCodeTimeout, "%s: %s did not complete within %s", attribute, argv[0], timeout)
+		}
+		return nil, fmt.Errorf("%s: %s failed: %s: %s", attribute, argv[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
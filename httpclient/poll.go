@@ -0,0 +1,63 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// pollOptions bound the polling of a request
+type pollOptions struct {
+	interval time.Duration
+	// maxAttempts is the number of attempts, unlimited when 0
+	maxAttempts int
+	// deadline stops the polling when the next attempt would start after
+	// it, none when zero
+	deadline time.Time
+	// retryErrors polls again after a failed request instead of failing
+	retryErrors bool
+}
+
+// pollExhausted is returned when the response is still not ready after the
+// last attempt, reason is the one of the last attempt
+type pollExhausted struct {
+	attempts int
+	reason   string
+}
+
+func (e *pollExhausted) Error() string {
+	return fmt.Sprintf("not ready after %d attempts: %s", e.attempts, e.reason)
+}
+
+// pollUntil sends the request until check accepts the response and returns
+// it with the number of attempts. check returns why the response is not
+// ready yet, empty when it is, an error stops the polling.
+func pollUntil(ctx context.Context, config *providerConfig, rc *RequestConfig, opts pollOptions, check func(*Response) (string, error)) (*Response, int, error) {
+	for attempt := 1; ; attempt++ {
+		var reason string
+		rsp, err := ExecuteRequest(ctx, config, rc)
+		switch {
+		case err != nil && !opts.retryErrors:
+			return rsp, attempt, err
+		case err != nil:
+			reason = fmt.Sprintf("%s (%s)", err, errorCode(err))
+		default:
+			if reason, err = check(rsp); err != nil {
+				return nil, attempt, err
+			}
+			if len(reason) == 0 {
+				return rsp, attempt, nil
+			}
+		}
+
+		if (opts.maxAttempts > 0 && attempt >= opts.maxAttempts) ||
+			(!opts.deadline.IsZero() && time.Now().Add(opts.interval).After(opts.deadline)) {
+			return nil, attempt, &pollExhausted{attempts: attempt, reason: reason}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, attempt, ctx.Err()
+		case <-time.After(opts.interval):
+		}
+	}
+}
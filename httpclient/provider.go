@@ -1,16 +1,281 @@
 package httpclient
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	neturl "net/url"
+	"os"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
 )
 
 // Provider -
 func Provider() *schema.Provider {
 	return &schema.Provider{
-		Schema:       map[string]*schema.Schema{},
-		ResourcesMap: map[string]*schema.Resource{},
+		Schema: map[string]*schema.Schema{
+			"client_cert_file": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Default:       "",
+				RequiredWith:  []string{"client_key_file"},
+				ConflictsWith: []string{"spiffe"},
+			},
+			"client_key_file": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				RequiredWith: []string{"client_cert_file"},
+			},
+			"spiffe": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"socket_path": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							DefaultFunc: schema.EnvDefaultFunc("SPIFFE_ENDPOINT_SOCKET", ""),
+						},
+					},
+				},
+			},
+			"dns_cache_ttl": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				ValidateFunc: validateDuration,
+			},
+			"dns_cache_exclude_hosts": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"tls_key_log_file": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "",
+			},
+			"proxy_url": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				ValidateFunc: validation.IsURLWithScheme(proxySchemes),
+			},
+			"no_proxy": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"enable_cache": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"cache_ttl": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "5m",
+				ValidateFunc: validateDuration,
+			},
+			"credentials": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"username": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"password": {
+							Type:      schema.TypeString,
+							Optional:  true,
+							Sensitive: true,
+						},
+						"bearer_token": {
+							Type:      schema.TypeString,
+							Optional:  true,
+							Sensitive: true,
+						},
+						"api_key": {
+							Type:      schema.TypeString,
+							Optional:  true,
+							Sensitive: true,
+						},
+						"api_key_header": {
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  "X-API-Key",
+						},
+					},
+				},
+			},
+			"strict": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"ca_bundle_url": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "",
+			},
+			"ca_bundle_sha256": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				RequiredWith: []string{"ca_bundle_url"},
+			},
+		},
+		ResourcesMap: map[string]*schema.Resource{
+			"httpclient_workflow": resourceWorkflow(),
+			"httpclient_request":  resourceRequest(),
+		},
 		DataSourcesMap: map[string]*schema.Resource{
-			"httpclient_request": dataSourceRequest(),
+			"httpclient_request":          dataSourceRequest(),
+			"httpclient_dns_check":        dataSourceDNSCheck(),
+			"httpclient_health_check_set": dataSourceHealthCheckSet(),
+			"httpclient_run_stats":        dataSourceRunStats(),
+			"httpclient_matrix":           dataSourceMatrix(),
+			"httpclient_download":         dataSourceDownload(),
+			"httpclient_health_check":     dataSourceHealthCheck(),
+			"httpclient_banner":           dataSourceBanner(),
+			"httpclient_session":          dataSourceSession(),
+			"httpclient_chain":            dataSourceChain(),
+			"httpclient_graphql":          dataSourceGraphQL(),
 		},
+		ConfigureContextFunc: providerConfigure,
+	}
+}
+
+// providerConfig is shared by all data sources and resources
+type providerConfig struct {
+	keyLogWriter   io.Writer
+	clientIdentity *clientIdentity
+	svidSource     x509svid.Source
+	dnsCache       *dnsCache
+	sessionCache   tls.ClientSessionCache
+	stats          *runStats
+	tokenCache     *tokenCache
+	rootCAs        *x509.CertPool
+	proxyURL       string
+	noProxy        []string
+	strict         bool
+	responseCache  *responseCache
+	credentials    map[string]credentialsProfile
+	sessions       *sessionJars
+	overrideHosts  map[string]*neturl.URL
+}
+
+func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	config := &providerConfig{
+		sessionCache: tls.NewLRUClientSessionCache(256),
+		stats:        newRunStats(),
+		tokenCache:   newTokenCache(),
+		sessions:     newSessionJars(),
+		strict:       d.Get("strict").(bool),
+	}
+
+	// client certificate for mutual tls
+	if cert_file := d.Get("client_cert_file").(string); len(cert_file) > 0 {
+		identity, err := newClientIdentity(cert_file, d.Get("client_key_file").(string))
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+		config.clientIdentity = identity
+	}
+
+	// client identity from the spiffe workload api
+	if spiffe := d.Get("spiffe").([]interface{}); len(spiffe) > 0 {
+		socket_path := ""
+		if spiffe[0] != nil {
+			socket_path = spiffe[0].(map[string]interface{})["socket_path"].(string)
+		}
+		source, err := newSVIDSource(socket_path)
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+		config.svidSource = source
+	}
+
+	// default proxy of the requests
+	config.proxyURL = d.Get("proxy_url").(string)
+	for _, host := range d.Get("no_proxy").([]interface{}) {
+		config.noProxy = append(config.noProxy, host.(string))
 	}
+
+	// trusted certificate authorities distributed from a central location
+	if url := d.Get("ca_bundle_url").(string); len(url) > 0 {
+		pool, err := fetchCABundle(ctx, url, d.Get("ca_bundle_sha256").(string))
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+		config.rootCAs = pool
+	}
+
+	// credentials selected by name by the requests
+	profiles, err := credentialsProfiles(d.Get("credentials").([]interface{}))
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+	config.credentials = profiles
+
+	// endpoints replaced by stub servers, for terraform test
+	if value := os.Getenv(overrideHostsEnv); len(value) > 0 {
+		overrides, err := parseOverrideHosts(value)
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+		config.overrideHosts = overrides
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Request hosts are overridden",
+			Detail:   overrideHostsEnv + " sends the requests to other endpoints: " + overrideHostsSummary(overrides),
+		})
+	}
+
+	// share the responses of identical requests
+	if d.Get("enable_cache").(bool) {
+		ttl, _ := time.ParseDuration(d.Get("cache_ttl").(string))
+		config.responseCache = newResponseCache(ttl)
+	}
+
+	// cache dns lookups
+	if ttl := d.Get("dns_cache_ttl").(string); len(ttl) > 0 {
+		duration, _ := time.ParseDuration(ttl)
+		var exclude []string
+		for _, host := range d.Get("dns_cache_exclude_hosts").([]interface{}) {
+			exclude = append(exclude, host.(string))
+		}
+		config.dnsCache = newDNSCache(duration, exclude)
+	}
+
+	// write tls session keys for debugging purpose
+	if path := d.Get("tls_key_log_file").(string); len(path) > 0 {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+		config.keyLogWriter = f
+
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "TLS key logging is enabled",
+			Detail: "TLS session keys are written to " + path + ", anyone with access to this file " +
+				"can decrypt the captured traffic. Only use this option for troubleshooting.",
+		})
+	}
+
+	return config, diags
 }
@@ -0,0 +1,140 @@
+package httpclient
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+	xproxy "golang.org/x/net/proxy"
+)
+
+// proxy schemes accepted by the proxy_url attribute
+var proxySchemes = []string{"http", "https", "socks5", "socks5h"}
+
+// proxyFunc returns the proxy selection of the transport, the request
+// settings take precedence over the provider ones, nil without proxy
+func proxyFunc(rc *RequestConfig, config *providerConfig) func(*http.Request) (*neturl.URL, error) {
+	proxy_url, no_proxy := config.proxyURL, config.noProxy
+	if len(rc.ProxyURL) > 0 {
+		proxy_url = rc.ProxyURL
+	}
+	if rc.NoProxy != nil {
+		no_proxy = rc.NoProxy
+	}
+	if len(proxy_url) == 0 {
+		return nil
+	}
+
+	// same matching rules as the NO_PROXY environment variable
+	proxy := (&httpproxy.Config{
+		HTTPProxy:  proxy_url,
+		HTTPSProxy: proxy_url,
+		NoProxy:    strings.Join(no_proxy, ","),
+	}).ProxyFunc()
+	return func(req *http.Request) (*neturl.URL, error) {
+		return proxy(req.URL)
+	}
+}
+
+// dialProxy opens a tcp connection to the address through the proxy of the
+// provider, a CONNECT tunnel for http proxies, directly without proxy
+func dialProxy(ctx context.Context, config *providerConfig, address string, dial dialFunc) (net.Conn, error) {
+	address = overrideAddress(address, config.overrideHosts)
+
+	var proxy_url *neturl.URL
+	if proxy := proxyFunc(&RequestConfig{}, config); proxy != nil {
+		var err error
+		proxy_url, err = proxy(&http.Request{URL: &neturl.URL{Scheme: "https", Host: address}})
+		if err != nil {
+			return nil, newRequestError(errorCodeConfig, "proxy: %s", err)
+		}
+	}
+	if proxy_url == nil {
+		return dial(ctx, "tcp", address)
+	}
+
+	switch proxy_url.Scheme {
+	case "socks5", "socks5h":
+		dialer, err := xproxy.FromURL(proxy_url, contextDialer(dial))
+		if err != nil {
+			return nil, newRequestError(errorCodeConfig, "proxy: %s", err)
+		}
+		return dialer.(xproxy.ContextDialer).DialContext(ctx, "tcp", address)
+	}
+
+	conn, err := dial(ctx, "tcp", proxy_url.Host)
+	if err != nil {
+		return nil, err
+	}
+	if proxy_url.Scheme == "https" {
+		tls_conn := tls.Client(conn, &tls.Config{ServerName: proxy_url.Hostname(), RootCAs: config.rootCAs})
+		if err := tls_conn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tls_conn
+	}
+
+	// open the tunnel
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &neturl.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+	if proxy_url.User != nil {
+		password, _ := proxy_url.User.Password()
+		req.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(proxy_url.User.Username()+":"+password)))
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	reader := bufio.NewReader(conn)
+	rsp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, newRequestError(errorCodeConnection, "proxy: CONNECT %s returned status %s", address, rsp.Status)
+	}
+
+	// data sent by the server right after the tunnel is established may
+	// already be buffered
+	return &bufferedConn{Conn: conn, reader: reader}, nil
+}
+
+// contextDialer adapts a dialFunc to the dialers of the proxy package
+type contextDialer dialFunc
+
+func (d contextDialer) Dial(network, address string) (net.Conn, error) {
+	return d(context.Background(), network, address)
+}
+
+func (d contextDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return d(ctx, network, address)
+}
+
+// bufferedConn reads the connection through a buffered reader
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
@@ -0,0 +1,676 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
+	neturl "net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RequestConfig describes an HTTP request and how to send it, it is shared
+// by every data source and resource performing requests
+type RequestConfig struct {
+	URL      string
+	Method   string
+	Headers  map[string]string
+	Body     []byte
+	Username string
+	Password string
+	// AuthType selects how the credentials are used, basic, registry,
+	// keystone or ntlm
+	AuthType string
+	// Credentials selects a credentials profile of the provider
+	Credentials string
+	// Session is the id of a httpclient_session, the cookies set by the
+	// responses are sent by the next requests of the session
+	Session string
+	// BearerToken is sent in the Authorization header, APIKey in the
+	// APIKeyHeader header
+	BearerToken  string
+	APIKey       string
+	APIKeyHeader string
+	// AWSSigV4 signs the request for AWS APIs
+	AWSSigV4 *AWSSigV4
+	// CredentialCommand prints the credentials to use, it is run just
+	// before the request is sent
+	CredentialCommand []string
+	// AuthRequest is a login request the credentials are sent to, the
+	// request is authenticated with headers derived from its response
+	AuthRequest *AuthRequest
+	// Keystone configures the keystone AuthType
+	Keystone *KeystoneAuth
+	// NTLM configures the ntlm AuthType
+	NTLM *NTLMAuth
+	// OAuth2 obtains an access token sent as a bearer token
+	OAuth2 *OAuth2
+
+	Insecure              bool
+	SkipTLSVerifyHostname bool
+	CheckRevocation       string
+	TLSRenegotiation      string
+
+	// DateHeaders are set to the current time when the request is sent
+	DateHeaders []DateHeader
+
+	// CompressBody is the Content-Encoding used to compress the body
+	CompressBody string
+
+	// UseSRVLookup resolves the url host as a SRV record
+	UseSRVLookup bool
+	// AllowedRemoteNetworks restricts the addresses connections can be
+	// established to, any address is allowed when empty
+	AllowedRemoteNetworks []*net.IPNet
+	// ProxyURL overrides the proxy of the provider, NoProxy lists the hosts
+	// reached directly, the provider ones are used when nil
+	ProxyURL string
+	NoProxy  []string
+
+	// Timeout of the whole exchange, defaults to defaultTimeout
+	Timeout time.Duration
+	// MaxBodySize is the maximum response body size in bytes, 0 for unlimited
+	MaxBodySize int64
+	// BodyWriter receives the body of successful responses instead of
+	// Response.Body, for downloads too large to be kept in memory
+	BodyWriter io.Writer
+
+	// HeadersOnly skips reading the response body
+	HeadersOnly bool
+	// PreflightHead validates the resource with a HEAD request first
+	PreflightHead             bool
+	PreflightContentType      string
+	PreflightMaxContentLength int64
+	// SuccessWhen is an expression the response must satisfy
+	SuccessWhen string
+	// Assertions are checked once SuccessWhen is satisfied
+	Assertions []Assertion
+	// Cacheable allows the response to be shared with identical requests
+	// when the provider cache is enabled
+	Cacheable bool
+
+	// RetryAttempts is the number of retries on transient failures, with
+	// an exponential backoff between RetryMinDelay and RetryMaxDelay
+	RetryAttempts      int
+	RetryMinDelay      time.Duration
+	RetryMaxDelay      time.Duration
+	RetryOnStatusCodes []int
+	// RetryUntilHeader retries until a response header converges
+	RetryUntilHeader *HeaderCondition
+	// RetryNonIdempotent retries the connection and timeout errors of the
+	// POST and PATCH requests, which may have reached the server
+	RetryNonIdempotent bool
+}
+
+// DateHeader is a header holding the time the request is sent, as expected
+// by signed APIs
+type DateHeader struct {
+	Name     string
+	Format   string
+	Location *time.Location
+}
+
+// date formats presets accepted by the date_header block, any other value
+// is used as a Go time layout
+var dateFormats = map[string]string{
+	"http":    http.TimeFormat,
+	"iso8601": time.RFC3339,
+	"amz":     "20060102T150405Z",
+}
+
+// Value formats the given time for the header
+func (h DateHeader) Value(now time.Time) string {
+	if h.Location != nil {
+		now = now.In(h.Location)
+	}
+
+	switch h.Format {
+	case "unix":
+		return strconv.FormatInt(now.Unix(), 10)
+	case "http", "amz":
+		// these formats are always expressed in utc
+		return now.UTC().Format(dateFormats[h.Format])
+	}
+
+	if layout, ok := dateFormats[h.Format]; ok {
+		return now.Format(layout)
+	}
+	return now.Format(h.Format)
+}
+
+// InformationalResponse is an interim 1xx response, such as 103 Early Hints
+type InformationalResponse struct {
+	StatusCode int
+	Headers    http.Header
+}
+
+// Response is the result of ExecuteRequest
+type Response struct {
+	// URL is the requested url without credentials
+	URL        string
+	StatusCode int
+	Status     string
+	Proto      string
+	Headers    http.Header
+	Body       []byte
+	// BodySize is the size of the body, read or streamed to BodyWriter
+	BodySize int64
+
+	// Request is the request as sent, with authentication headers
+	Request   *http.Request
+	StartedAt time.Time
+	Duration  time.Duration
+
+	RevocationStatus string
+	// TLSReport describes the server certificate, nil without tls
+	TLSReport *TLSReport
+	// ALPNProtocol is the protocol negotiated with ALPN, empty without tls
+	ALPNProtocol string
+
+	// Informational are the 1xx responses received before the final one
+	Informational []InformationalResponse
+
+	// RemoteAddr is the address of the connection, the proxy one when ViaProxy
+	RemoteAddr string
+	ViaProxy   bool
+}
+
+// defaultTimeout applies when the request does not set one
+const defaultTimeout = 10 * time.Second
+
+// ExecuteRequest sends the request and reads the response
+func ExecuteRequest(ctx context.Context, config *providerConfig, rc *RequestConfig) (*Response, error) {
+	// reuse the response of an identical request
+	cache_key := responseCacheKey(rc)
+	if rsp, ok := config.responseCache.get(cache_key, time.Now()); ok {
+		return rsp, nil
+	}
+
+	started_at := time.Now()
+	rsp, err := executeWithRetry(ctx, config, rc)
+	config.stats.record(rsp, err, time.Since(started_at))
+	if err == nil {
+		config.responseCache.set(cache_key, rsp, time.Now())
+	}
+	return rsp, err
+}
+
+func executeAuthRequest(ctx context.Context, config *providerConfig, rc *RequestConfig) (*Response, error) {
+	if len(rc.Credentials) > 0 {
+		var err error
+		rc, err = withCredentialsProfile(config, rc)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(rc.BearerToken) > 0 {
+		rc = withAuthHeaders(rc, map[string]string{"Authorization": "Bearer " + rc.BearerToken})
+	}
+	if len(rc.APIKey) > 0 {
+		rc = withAuthHeaders(rc, map[string]string{rc.APIKeyHeader: rc.APIKey})
+	}
+	if len(rc.CredentialCommand) > 0 {
+		credentials, err := runCredentialCommand(ctx, rc.CredentialCommand)
+		if err != nil {
+			return nil, err
+		}
+		rc = withCommandCredentials(rc, credentials)
+	}
+	if rc.AuthRequest != nil {
+		auth_headers, err := executeLoginRequest(ctx, config, rc)
+		if err != nil {
+			return nil, err
+		}
+		rc = withAuthHeaders(rc, auth_headers)
+	}
+	if rc.AuthType == authKeystone {
+		token, err := keystoneToken(ctx, config, rc)
+		if err != nil {
+			return nil, err
+		}
+		rc = withAuthHeaders(rc, map[string]string{"X-Auth-Token": token})
+	}
+	if rc.OAuth2 != nil {
+		token, err := oauth2Token(ctx, config, rc)
+		if err != nil {
+			return nil, err
+		}
+		rc = withAuthHeaders(rc, map[string]string{"Authorization": "Bearer " + token})
+	}
+
+	if rc.AuthType == authRegistry {
+		return executeRegistryRequest(ctx, config, rc)
+	}
+	return executeTargetRequest(ctx, config, rc)
+}
+
+// executeRegistryRequest sends the request anonymously first, then with a
+// bearer token obtained from the realm of the 401 challenge, as expected by
+// Docker registry v2 and OCI distribution registries
+func executeRegistryRequest(ctx context.Context, config *providerConfig, rc *RequestConfig) (*Response, error) {
+	// credentials are only sent to the token endpoint
+	credentials_rc := *rc
+	anonymous_rc := *rc
+	anonymous_rc.URL, credentials_rc.Username, credentials_rc.Password = registryCredentials(rc)
+	anonymous_rc.Username = ""
+	anonymous_rc.Password = ""
+
+	rsp, err := executeTargetRequest(ctx, config, &anonymous_rc)
+	if rsp == nil || rsp.StatusCode != http.StatusUnauthorized {
+		return rsp, err
+	}
+
+	token, err := fetchRegistryToken(ctx, config, &credentials_rc, rsp.Headers.Get("WWW-Authenticate"))
+	if err != nil {
+		return nil, err
+	}
+
+	headers := map[string]string{"Authorization": "Bearer " + token}
+	for name, value := range rc.Headers {
+		if !strings.EqualFold(name, "Authorization") {
+			headers[name] = value
+		}
+	}
+	anonymous_rc.Headers = headers
+
+	return executeTargetRequest(ctx, config, &anonymous_rc)
+}
+
+// registryCredentials returns the url without credentials and the
+// credentials to use with the token endpoint
+func registryCredentials(rc *RequestConfig) (string, string, string) {
+	url, username, password, err := splitURLCredentials(rc.URL)
+	if err != nil {
+		return rc.URL, rc.Username, rc.Password
+	}
+	if len(rc.Username) > 0 {
+		return url, rc.Username, rc.Password
+	}
+	return url, username, password
+}
+
+// executeTargetRequest sends the request, resolving the target first when
+// the host is a SRV record
+func executeTargetRequest(ctx context.Context, config *providerConfig, rc *RequestConfig) (*Response, error) {
+	if rc.UseSRVLookup {
+		return executeSRVRequest(ctx, config, rc)
+	}
+	return executeRequest(ctx, config, rc)
+}
+
+// executeSRVRequest resolves the SRV record named by the url host and sends
+// the request to the targets by priority and weight, the next target is
+// tried when the connection fails
+func executeSRVRequest(ctx context.Context, config *providerConfig, rc *RequestConfig) (*Response, error) {
+	u, err := neturl.Parse(rc.URL)
+	if err != nil {
+		return nil, &RequestError{Code: errorCodeConfig, Err: errInvalidURL}
+	}
+
+	// an overridden record name is sent to its stub without lookup
+	if _, ok := lookupOverride(config.overrideHosts, u.Host, u.Hostname()); ok {
+		return executeRequest(ctx, config, rc)
+	}
+
+	// targets are sorted by priority and randomized by weight
+	_, targets, err := net.DefaultResolver.LookupSRV(ctx, "", "", u.Hostname())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, target := range targets {
+		u.Host = net.JoinHostPort(strings.TrimSuffix(target.Target, "."), strconv.Itoa(int(target.Port)))
+
+		target_rc := *rc
+		target_rc.URL = u.String()
+
+		rsp, err := executeRequest(ctx, config, &target_rc)
+		var op_err *net.OpError
+		if err != nil && rsp == nil && errors.As(err, &op_err) && op_err.Op == "dial" {
+			continue
+		}
+		return rsp, err
+	}
+	return nil, newRequestError(errorCodeConnection, "no reachable target for SRV record %s", u.Hostname())
+}
+
+// executeRequest sends the request to the url as is
+func executeRequest(ctx context.Context, config *providerConfig, rc *RequestConfig) (*Response, error) {
+
+	// extract credentials embedded in the url, explicit ones take precedence
+	url, url_username, url_password, err := splitURLCredentials(rc.URL)
+	if err != nil {
+		return nil, &RequestError{Code: errorCodeConfig, Err: err}
+	}
+	url = overrideURL(url, config.overrideHosts)
+	username, password := rc.Username, rc.Password
+	if len(username) == 0 {
+		username = url_username
+		password = url_password
+	}
+
+	// only headers are needed, a HEAD is equivalent to a GET without body
+	method := rc.Method
+	if rc.HeadersOnly && method == http.MethodGet {
+		method = http.MethodHead
+	}
+
+	// compress the payload
+	body := rc.Body
+	if len(rc.CompressBody) > 0 {
+		body, err = compressBody(body, rc.CompressBody)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// init http request
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, &RequestError{Code: errorCodeConfig, Err: err}
+	}
+
+	// set basic auth ? ntlm authenticates with a handshake instead
+	if rc.AuthType == authNTLM && len(username) == 0 {
+		return nil, newRequestError(errorCodeConfig, "ntlm authentication: username and password are required with auth_type ntlm")
+	}
+	if len(username) > 0 && rc.AuthType != authNTLM {
+		req.SetBasicAuth(username, password)
+	}
+
+	// add headers
+	for name, value := range rc.Headers {
+		req.Header.Set(name, value)
+	}
+	if len(rc.CompressBody) > 0 {
+		req.Header.Set("Content-Encoding", rc.CompressBody)
+	}
+	now := time.Now()
+	for _, header := range rc.DateHeaders {
+		req.Header.Set(header.Name, header.Value(now))
+	}
+
+	// sign the final headers and body
+	if rc.AWSSigV4 != nil {
+		if err := signAWSSigV4(req, body, rc.AWSSigV4, now); err != nil {
+			return nil, err
+		}
+	}
+
+	// init go client and send request
+	tls_info := &tlsInfo{}
+	tr := &http.Transport{
+		TLSClientConfig: newTLSConfig(rc, config, tls_info),
+		// offer h2 with ALPN despite the custom tls configuration
+		ForceAttemptHTTP2: true,
+		Proxy:             proxyFunc(rc, config),
+	}
+	if config.dnsCache != nil {
+		tr.DialContext = config.dnsCache.DialContext
+	}
+	if len(rc.AllowedRemoteNetworks) > 0 {
+		dial := tr.DialContext
+		if dial == nil {
+			dial = (&net.Dialer{}).DialContext
+		}
+		tr.DialContext = restrictRemoteAddr(dial, rc.AllowedRemoteNetworks)
+	}
+
+	timeout := rc.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	client := &http.Client{Transport: tr, Timeout: timeout}
+	if len(rc.Session) > 0 && config.sessions != nil {
+		client.Jar = config.sessions.jar(rc.Session)
+	}
+	if rc.AuthType == authNTLM {
+		// the handshake authenticates a http/1.1 connection
+		tr.ForceAttemptHTTP2 = false
+		ntlm := NTLMAuth{}
+		if rc.NTLM != nil {
+			ntlm = *rc.NTLM
+		}
+		client.Transport = &ntlmTransport{base: tr, username: username, password: password, ntlm: ntlm}
+	}
+
+	// fail fast before downloading a body which does not match expectations
+	if rc.PreflightHead {
+		if err := preflightHead(client, req, rc); err != nil {
+			return nil, err
+		}
+	}
+
+	// keep track of the connection actually used
+	var remote_addr string
+	var informational []InformationalResponse
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			remote_addr = info.Conn.RemoteAddr().String()
+		},
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			informational = append(informational, InformationalResponse{StatusCode: code, Headers: http.Header(header).Clone()})
+			return nil
+		},
+	}
+	phases := &requestPhases{}
+	phases.trace(trace)
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	started_at := time.Now()
+	r, err := client.Do(req)
+	if err != nil && tls_info.clientCertRequested && isClientAuthAlert(err) {
+		return nil, newRequestError(errorCodeClientAuth, "%s: the server requested a client certificate but none is configured, "+
+			"set client_cert_file and client_key_file (or spiffe) in the provider configuration", err)
+	}
+	if err != nil {
+		return nil, phases.timeoutError(err)
+	}
+	defer r.Body.Close()
+	phases.enter(phaseBody)
+
+	rsp := &Response{
+		URL:              url,
+		StatusCode:       r.StatusCode,
+		Status:           r.Status,
+		Proto:            r.Proto,
+		Headers:          r.Header,
+		Request:          req,
+		StartedAt:        started_at,
+		RevocationStatus: tls_info.revocationStatus,
+		TLSReport:        newTLSReport(r.TLS, req.URL.Hostname(), tr.TLSClientConfig.RootCAs, time.Now()),
+		RemoteAddr:       remote_addr,
+		Informational:    informational,
+	}
+	if r.TLS != nil {
+		rsp.ALPNProtocol = r.TLS.NegotiatedProtocol
+	}
+	if tr.Proxy != nil {
+		proxy_url, _ := tr.Proxy(req)
+		rsp.ViaProxy = proxy_url != nil
+	}
+
+	// read response body, discarded when only headers are needed and
+	// streamed without being kept in memory for successful downloads
+	switch {
+	case rc.HeadersOnly:
+	case rc.BodyWriter != nil && r.StatusCode >= 200 && r.StatusCode <= 299:
+		rsp.BodySize, err = streamBody(rc.BodyWriter, r.Body, rc.MaxBodySize)
+		if err != nil {
+			return nil, phases.timeoutError(err)
+		}
+	default:
+		rsp.Body, err = readBody(r.Body, rc.MaxBodySize)
+		if err != nil {
+			return nil, phases.timeoutError(err)
+		}
+		rsp.BodySize = int64(len(rsp.Body))
+	}
+	rsp.Duration = time.Since(started_at)
+
+	// check the response against the success criteria, the response is
+	// returned along with the error for troubleshooting
+	if len(rc.SuccessWhen) > 0 {
+		ok, err := evalBoolExpression(rc.SuccessWhen, rsp.expressionContext())
+		if err != nil {
+			return rsp, newRequestError(errorCodeBodyDecode, "unable to evaluate success_when: %s", err)
+		}
+		if !ok {
+			return rsp, newRequestError(errorCodeStatus, "response with status %d does not satisfy success_when: %s", rsp.StatusCode, rc.SuccessWhen)
+		}
+	}
+	if err := checkAssertions(rsp, rc.Assertions); err != nil {
+		return rsp, err
+	}
+
+	return rsp, nil
+}
+
+// streamBody copies the response body to w, failing when larger than max bytes
+func streamBody(w io.Writer, body io.Reader, max int64) (int64, error) {
+	if max <= 0 {
+		return io.Copy(w, body)
+	}
+
+	n, err := io.Copy(w, io.LimitReader(body, max+1))
+	if err != nil {
+		return n, err
+	}
+	if n > max {
+		return n, newRequestError(errorCodePolicy, "response body exceeds the maximum size of %d bytes", max)
+	}
+	return n, nil
+}
+
+// readBody reads the response body, failing when larger than max bytes
+func readBody(body io.Reader, max int64) ([]byte, error) {
+	if max <= 0 {
+		return io.ReadAll(body)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > max {
+		return nil, newRequestError(errorCodePolicy, "response body exceeds the maximum size of %d bytes", max)
+	}
+	return data, nil
+}
+
+// preflightHead sends a HEAD request for the same resource and checks the
+// status, content type and announced length
+func preflightHead(client *http.Client, req *http.Request, rc *RequestConfig) error {
+	head := req.Clone(req.Context())
+	head.Method = http.MethodHead
+	head.Body = http.NoBody
+	head.GetBody = nil
+	head.ContentLength = 0
+
+	r, err := client.Do(head)
+	if err != nil {
+		return fmt.Errorf("preflight HEAD request failed: %w", err)
+	}
+	r.Body.Close()
+
+	if r.StatusCode < 200 || r.StatusCode > 299 {
+		return newRequestError(errorCodeStatus, "preflight HEAD request returned status %d", r.StatusCode)
+	}
+
+	if len(rc.PreflightContentType) > 0 {
+		content_type, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if !strings.EqualFold(content_type, rc.PreflightContentType) {
+			return newRequestError(errorCodePolicy, "preflight HEAD request returned content type %q, expected %q", content_type, rc.PreflightContentType)
+		}
+	}
+
+	if rc.PreflightMaxContentLength > 0 && r.ContentLength > rc.PreflightMaxContentLength {
+		return newRequestError(errorCodePolicy, "preflight HEAD request announced %d bytes, more than the %d bytes allowed", r.ContentLength, rc.PreflightMaxContentLength)
+	}
+
+	return nil
+}
+
+// NegotiatedProtocol returns the protocol of the response as h1, h2 or h3
+func (rsp *Response) NegotiatedProtocol() string {
+	switch {
+	case strings.HasPrefix(rsp.Proto, "HTTP/3"):
+		return "h3"
+	case strings.HasPrefix(rsp.Proto, "HTTP/2"):
+		return "h2"
+	default:
+		return "h1"
+	}
+}
+
+func (rsp *Response) expressionContext() *expressionContext {
+	return &expressionContext{code: rsp.StatusCode, headers: rsp.Headers, body: rsp.Body}
+}
+
+// requestHash returns a stable hash of the request, independent of the
+// response and of the credentials, usable as an id
+func requestHash(rc *RequestConfig) string {
+	url, _, _, err := splitURLCredentials(rc.URL)
+	if err != nil {
+		url = rc.URL
+	}
+
+	names := make([]string, 0, len(rc.Headers))
+	for name := range rc.Headers {
+		names = append(names, http.CanonicalHeaderKey(name))
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n", rc.Method, url)
+	for _, name := range names {
+		fmt.Fprintf(h, "%s: %s\n", name, headerValue(rc.Headers, name))
+	}
+	fmt.Fprintf(h, "\n")
+	h.Write(rc.Body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// headerValue returns the value of a header from a map with any name case
+func headerValue(headers map[string]string, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+// errInvalidURL replaces the url parse errors, which quote the url
+var errInvalidURL = errors.New("invalid url")
+
+// splitURLCredentials removes the userinfo part from the url and returns it
+// separately, so credentials never end up in the id, logs or error messages
+func splitURLCredentials(rawURL string) (string, string, string, error) {
+	// the parse error quotes the whole url, credentials included
+	u, err := neturl.Parse(rawURL)
+	if err != nil {
+		return "", "", "", errInvalidURL
+	}
+	if u.User == nil {
+		return rawURL, "", "", nil
+	}
+
+	username := u.User.Username()
+	password, _ := u.User.Password()
+	u.User = nil
+
+	return u.String(), username, password, nil
+}
@@ -0,0 +1,359 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/id"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// lifecycleRequestSchema is the block of the request sent for a lifecycle phase
+func lifecycleRequestSchema(method string, required bool, extra map[string]*schema.Schema) *schema.Schema {
+	block := map[string]*schema.Schema{
+		"url": {
+			Type:     schema.TypeString,
+			Required: true,
+		},
+		"request_method": {
+			Type:     schema.TypeString,
+			Optional: true,
+			Default:  method,
+		},
+		"request_headers": {
+			Type:     schema.TypeMap,
+			Optional: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		},
+		"request_body": {
+			Type:     schema.TypeString,
+			Optional: true,
+			Default:  "",
+		},
+		"success_when": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      "",
+			ValidateFunc: validateExpression,
+		},
+	}
+	for name, s := range extra {
+		block[name] = s
+	}
+
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Required: required,
+		Optional: !required,
+		MaxItems: 1,
+		Elem:     &schema.Resource{Schema: block},
+	}
+}
+
+func resourceRequest() *schema.Resource {
+	resource := &schema.Resource{
+		CreateContext: resourceRequestCreate,
+		ReadContext:   resourceRequestRead,
+		UpdateContext: resourceRequestUpdate,
+		DeleteContext: resourceRequestDelete,
+		CustomizeDiff: resourceRequestCustomizeDiff,
+		Schema: map[string]*schema.Schema{
+			"create": lifecycleRequestSchema(http.MethodPost, true, map[string]*schema.Schema{
+				"extract": {
+					Type:     schema.TypeMap,
+					Optional: true,
+					Elem:     &schema.Schema{Type: schema.TypeString},
+				},
+			}),
+			"read":   lifecycleRequestSchema(http.MethodGet, false, nil),
+			"update": lifecycleRequestSchema(http.MethodPut, false, nil),
+			"delete": lifecycleRequestSchema(http.MethodDelete, false, nil),
+			"id_path": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Default:  "",
+			},
+			"timeout": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "10s",
+				ValidateFunc: validateDuration,
+			},
+			"session": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "",
+			},
+			"detect_drift": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"replace_on_change": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringMatch(lifecyclePathPattern, "must be a lifecycle block argument such as create.url"),
+				},
+			},
+			"ignore_changes_server_side": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"outputs": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"response_code": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"response_headers": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"response_body": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+	for name, s := range connectionSchema() {
+		resource.Schema[name] = s
+	}
+	return resource
+}
+
+// lifecyclePathPattern validates the paths of replace_on_change
+var lifecyclePathPattern = regexp.MustCompile(`^(create|read|update|delete)\.(url|request_method|request_headers|request_body|success_when)$`)
+
+// resourceRequestCustomizeDiff replaces the resource when the create request
+// changes and there is no update request to apply the change, or when an
+// argument listed in replace_on_change changes
+func resourceRequestCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if len(d.Get("update").([]interface{})) == 0 && d.HasChange("create") {
+		return d.ForceNew("create")
+	}
+
+	for _, path := range d.Get("replace_on_change").([]interface{}) {
+		block, argument, _ := strings.Cut(path.(string), ".")
+		key := block + ".0." + argument
+		if d.HasChange(key) {
+			if err := d.ForceNew(key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func resourceRequestCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*providerConfig)
+	block := lifecycleBlock(d, "create")
+
+	rsp, err := executeLifecycleRequest(ctx, config, d, block, map[string]string{})
+	if err != nil {
+		return diag.Errorf("create: %s", err)
+	}
+
+	// values referenced by the other requests as {{create.key}}
+	outputs := make(map[string]string)
+	if err := extractOutputs("create", rsp.Body, block["extract"].(map[string]interface{}), outputs); err != nil {
+		return diag.Errorf("create: %s", err)
+	}
+
+	// identify the remote object by a value of the response
+	resource_id := id.UniqueId()
+	if id_path := d.Get("id_path").(string); len(id_path) > 0 {
+		doc, err := decodeJSON(rsp.Body)
+		if err != nil {
+			return diag.Errorf("create: response body is not valid json: %s", err)
+		}
+		value, found, err := jsonPathLookup(doc, id_path)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if !found {
+			return diag.Errorf("create: %s not found in response body", id_path)
+		}
+		resource_id = jsonValueString(value)
+	}
+
+	d.Set("outputs", outputs)
+	setLifecycleResponse(d, rsp)
+	d.SetId(resource_id)
+
+	return resourceRequestRead(ctx, d, m)
+}
+
+func resourceRequestRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*providerConfig)
+	block := lifecycleBlock(d, "read")
+	if block == nil {
+		return nil
+	}
+
+	rsp, err := executeLifecycleRequest(ctx, config, d, block, lifecycleValues(d))
+	if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+		// eventually consistent apis may not return the object just
+		// created, otherwise the remote object is gone and it will be
+		// created again
+		if !d.IsNewResource() {
+			d.SetId("")
+		}
+		return nil
+	}
+	if err != nil {
+		return diag.Errorf("read: %s", err)
+	}
+
+	if !d.Get("detect_drift").(bool) {
+		return nil
+	}
+
+	// report the remote values of the fields of the create body, so that a
+	// change made outside of terraform shows in the plan
+	create := d.Get("create").([]interface{})
+	var ignored []string
+	for _, field := range d.Get("ignore_changes_server_side").([]interface{}) {
+		ignored = append(ignored, field.(string))
+	}
+	observed, drifted, err := observedBody(create[0].(map[string]interface{})["request_body"].(string), rsp.Body, ignored)
+	if err != nil {
+		return diag.Errorf("read: %s", err)
+	}
+	if drifted {
+		create[0].(map[string]interface{})["request_body"] = observed
+		d.Set("create", create)
+	}
+
+	return nil
+}
+
+func resourceRequestUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*providerConfig)
+	block := lifecycleBlock(d, "update")
+
+	// only the create or update requests describe the remote object
+	if block != nil && d.HasChanges("create", "update") {
+		rsp, err := executeLifecycleRequest(ctx, config, d, block, lifecycleValues(d))
+		if err != nil {
+			return diag.Errorf("update: %s", err)
+		}
+		setLifecycleResponse(d, rsp)
+	}
+
+	return resourceRequestRead(ctx, d, m)
+}
+
+func resourceRequestDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*providerConfig)
+
+	if block := lifecycleBlock(d, "delete"); block != nil {
+		rsp, err := executeLifecycleRequest(ctx, config, d, block, lifecycleValues(d))
+		if err != nil && (rsp == nil || rsp.StatusCode != http.StatusNotFound) {
+			return diag.Errorf("delete: %s", err)
+		}
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// lifecycleBlock returns the request block of a phase, nil when not set
+func lifecycleBlock(d *schema.ResourceData, phase string) map[string]interface{} {
+	blocks := d.Get(phase).([]interface{})
+	if len(blocks) == 0 || blocks[0] == nil {
+		return nil
+	}
+	return blocks[0].(map[string]interface{})
+}
+
+// lifecycleValues are the values the request templates can reference, the
+// outputs of the create request and the id
+func lifecycleValues(d *schema.ResourceData) map[string]string {
+	values := map[string]string{"id": d.Id()}
+	for key, value := range d.Get("outputs").(map[string]interface{}) {
+		values[key] = value.(string)
+	}
+	return values
+}
+
+// executeLifecycleRequest renders the block templates and sends the request,
+// a response outside of 2xx is an error unless success_when is set
+func executeLifecycleRequest(ctx context.Context, config *providerConfig, d *schema.ResourceData, block map[string]interface{}, values map[string]string) (*Response, error) {
+	rc, err := workflowStepRequest(block, values)
+	if err != nil {
+		return nil, err
+	}
+	if err := connectionFromData(d, rc); err != nil {
+		return nil, err
+	}
+	rc.Session = d.Get("session").(string)
+	rc.Timeout, _ = time.ParseDuration(d.Get("timeout").(string))
+	if len(rc.SuccessWhen) == 0 {
+		rc.SuccessWhen = defaultHealthyWhen
+	}
+
+	return ExecuteRequest(ctx, config, rc)
+}
+
+// setLifecycleResponse stores the response of the create or update request
+func setLifecycleResponse(d *schema.ResourceData, rsp *Response) {
+	d.Set("response_code", rsp.StatusCode)
+	// the cookies of a session stay in memory
+	headers := flattenHeaders(rsp.Headers)
+	if len(d.Get("session").(string)) > 0 {
+		delete(headers, "Set-Cookie")
+	}
+	d.Set("response_headers", headers)
+	d.Set("response_body", string(rsp.Body))
+}
+
+// observedBody returns the configured json object with the values of the
+// read response, and whether they differ from the configured ones, the
+// ignored fields keep their configured value
+func observedBody(configured string, body []byte, ignored []string) (string, bool, error) {
+	var desired map[string]interface{}
+	if err := json.Unmarshal([]byte(configured), &desired); err != nil {
+		// only json objects are compared
+		return configured, false, nil
+	}
+
+	var remote map[string]interface{}
+	if err := json.Unmarshal(body, &remote); err != nil {
+		return "", false, fmt.Errorf("response body is not a json object: %s", err)
+	}
+
+	observed := make(map[string]interface{}, len(desired))
+	for key := range desired {
+		observed[key] = remote[key]
+	}
+	for _, key := range ignored {
+		if value, ok := desired[key]; ok {
+			observed[key] = value
+		}
+	}
+	if reflect.DeepEqual(observed, desired) {
+		return configured, false, nil
+	}
+
+	b, err := json.Marshal(observed)
+	if err != nil {
+		return "", false, err
+	}
+	return string(b), true, nil
+}
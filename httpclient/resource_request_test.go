@@ -0,0 +1,55 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestResourceRequestCreate(t *testing.T) {
+	reads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the requests are authenticated like the data source ones
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method {
+		case http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"1"}`))
+		case http.MethodGet:
+			// the object is not readable yet just after its creation
+			reads++
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	d := schema.TestResourceDataRaw(t, resourceRequest().Schema, map[string]interface{}{
+		"create":       []interface{}{map[string]interface{}{"url": server.URL + "/items", "request_body": `{"name":"a"}`}},
+		"read":         []interface{}{map[string]interface{}{"url": server.URL + "/items/{{id}}"}},
+		"id_path":      "$.id",
+		"bearer_token": "secret",
+	})
+	d.MarkNewResource()
+	if diags := resourceRequestCreate(context.Background(), d, newTestConfig()); diags.HasError() {
+		t.Fatalf("unexpected errors: %v", diags)
+	}
+	if d.Id() != "1" || reads != 1 {
+		t.Errorf("id is %q after %d reads, expected 1 after 1 read", d.Id(), reads)
+	}
+
+	// a later 404 means the object is gone
+	state := d.State()
+	d = resourceRequest().Data(state)
+	if diags := resourceRequestRead(context.Background(), d, newTestConfig()); diags.HasError() {
+		t.Fatalf("unexpected errors: %v", diags)
+	}
+	if len(d.Id()) > 0 {
+		t.Errorf("id is %q, expected the resource to be removed", d.Id())
+	}
+}
@@ -0,0 +1,248 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/id"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// workflowStepsSchema is the ordered list of requests of a workflow, values
+// extracted by a step are referenced by the next ones as {{step.key}}
+func workflowStepsSchema(force_new bool) *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Required: true,
+		ForceNew: force_new,
+		MinItems: 1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"name": {
+					Type:         schema.TypeString,
+					Required:     true,
+					ForceNew:     force_new,
+					ValidateFunc: validation.StringMatch(templateName, "must only contain letters, digits, - and _"),
+				},
+				"url": {
+					Type:     schema.TypeString,
+					Required: true,
+					ForceNew: force_new,
+				},
+				"request_method": {
+					Type:     schema.TypeString,
+					Optional: true,
+					ForceNew: force_new,
+					Default:  "GET",
+				},
+				"request_headers": {
+					Type:     schema.TypeMap,
+					Optional: true,
+					ForceNew: force_new,
+					Elem:     &schema.Schema{Type: schema.TypeString},
+				},
+				"request_body": {
+					Type:     schema.TypeString,
+					Optional: true,
+					ForceNew: force_new,
+					Default:  "",
+				},
+				"success_when": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					ForceNew:     force_new,
+					Default:      "",
+					ValidateFunc: validateExpression,
+				},
+				"poll_until": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					ForceNew:     force_new,
+					Default:      "",
+					ValidateFunc: validateExpression,
+				},
+				"poll_interval": {
+					Type:         schema.TypeInt,
+					Optional:     true,
+					ForceNew:     force_new,
+					Default:      5,
+					ValidateFunc: validation.IntAtLeast(1),
+				},
+				"poll_max_attempts": {
+					Type:         schema.TypeInt,
+					Optional:     true,
+					ForceNew:     force_new,
+					Default:      10,
+					ValidateFunc: validation.IntAtLeast(1),
+				},
+				"extract": {
+					Type:     schema.TypeMap,
+					Optional: true,
+					ForceNew: force_new,
+					Elem:     &schema.Schema{Type: schema.TypeString},
+				},
+			},
+		},
+	}
+}
+
+func resourceWorkflow() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceWorkflowCreate,
+		ReadContext:   resourceWorkflowRead,
+		DeleteContext: resourceWorkflowDelete,
+		Schema: map[string]*schema.Schema{
+			"insecure": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				ForceNew: true,
+				Default:  false,
+			},
+			"step": workflowStepsSchema(true),
+			"outputs": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func resourceWorkflowCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	config := m.(*providerConfig)
+
+	outputs, _, err := executeWorkflowSteps(ctx, config, d.Get("step").([]interface{}), d.Get("insecure").(bool))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.Set("outputs", outputs)
+	d.SetId(id.UniqueId())
+
+	return nil
+}
+
+// executeWorkflowSteps sends the steps in order and returns the extracted
+// values and the response of the last step
+func executeWorkflowSteps(ctx context.Context, config *providerConfig, steps []interface{}, insecure bool) (map[string]string, *Response, error) {
+	// values extracted by the previous steps, referenced as {{step.name}}
+	outputs := make(map[string]string)
+
+	var rsp *Response
+	for _, raw := range steps {
+		step := raw.(map[string]interface{})
+		name := step["name"].(string)
+
+		rc, err := workflowStepRequest(step, outputs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("step %s: %s", name, err)
+		}
+		rc.Insecure = insecure
+
+		rsp, err = executeWorkflowStep(ctx, config, rc, step)
+		if err != nil {
+			return nil, nil, fmt.Errorf("step %s: %s", name, err)
+		}
+
+		// extract values for the next steps
+		if err := extractOutputs(name, rsp.Body, step["extract"].(map[string]interface{}), outputs); err != nil {
+			return nil, nil, fmt.Errorf("step %s: %s", name, err)
+		}
+	}
+	return outputs, rsp, nil
+}
+
+// workflowStepRequest renders the step templates with the values already extracted
+func workflowStepRequest(step map[string]interface{}, outputs map[string]string) (*RequestConfig, error) {
+	url, err := renderTemplate(step["url"].(string), outputs)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := renderTemplate(step["request_body"].(string), outputs)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]string)
+	for name, value := range step["request_headers"].(map[string]interface{}) {
+		headers[name], err = renderTemplate(value.(string), outputs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &RequestConfig{
+		URL:         url,
+		Method:      step["request_method"].(string),
+		Headers:     headers,
+		Body:        []byte(body),
+		SuccessWhen: step["success_when"].(string),
+	}, nil
+}
+
+// extractOutputs looks up the json paths in the body and stores the values
+// in outputs as name.key
+func extractOutputs(name string, body []byte, extract map[string]interface{}, outputs map[string]string) error {
+	if len(extract) == 0 {
+		return nil
+	}
+
+	doc, err := decodeJSON(body)
+	if err != nil {
+		return fmt.Errorf("response body is not valid json: %s", err)
+	}
+	for key, path := range extract {
+		value, found, err := jsonPathLookup(doc, path.(string))
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("%s not found in response body", path)
+		}
+		outputs[name+"."+key] = jsonValueString(value)
+	}
+	return nil
+}
+
+// executeWorkflowStep sends the step request, repeated until poll_until is satisfied
+func executeWorkflowStep(ctx context.Context, config *providerConfig, rc *RequestConfig, step map[string]interface{}) (*Response, error) {
+	poll_until := step["poll_until"].(string)
+	interval := time.Duration(step["poll_interval"].(int)) * time.Second
+	attempts := step["poll_max_attempts"].(int)
+
+	if len(poll_until) == 0 {
+		return ExecuteRequest(ctx, config, rc)
+	}
+
+	rsp, _, err := pollUntil(ctx, config, rc, pollOptions{interval: interval, maxAttempts: attempts}, func(rsp *Response) (string, error) {
+		done, err := evalBoolExpression(poll_until, rsp.expressionContext())
+		if err != nil {
+			return "", fmt.Errorf("unable to evaluate poll_until: %s", err)
+		}
+		if !done {
+			return "poll_until not satisfied", nil
+		}
+		return "", nil
+	})
+	var exhausted *pollExhausted
+	if errors.As(err, &exhausted) {
+		return nil, fmt.Errorf("poll_until not satisfied after %d attempts: %s", exhausted.attempts, poll_until)
+	}
+	return rsp, err
+}
+
+func resourceWorkflowRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// a workflow is only executed on creation, the state is kept as is
+	return nil
+}
+
+func resourceWorkflowDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,21 @@
+package httpclient
+
+import (
+	"testing"
+)
+
+func TestExtractOutputsLargeNumbers(t *testing.T) {
+	outputs := make(map[string]string)
+	err := extractOutputs("create", []byte(`{"id":1234567890123456789}`), map[string]interface{}{"id": "$.id"}, outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outputs["create.id"] != "1234567890123456789" {
+		t.Errorf("create.id is %s", outputs["create.id"])
+	}
+
+	// trailing data is rejected like json.Unmarshal does
+	if err := extractOutputs("create", []byte(`{"id":1} {}`), map[string]interface{}{"id": "$.id"}, outputs); err == nil {
+		t.Error("expected an error for trailing data")
+	}
+}
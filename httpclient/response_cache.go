@@ -0,0 +1,89 @@
+package httpclient
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// responseCache keeps the responses of GET requests for the provider run,
+// so that data sources requesting the same document share one request
+type responseCache struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	responses map[string]cachedResponse
+}
+
+type cachedResponse struct {
+	response  Response
+	expiresAt time.Time
+}
+
+func newResponseCache(ttl time.Duration) *responseCache {
+	return &responseCache{ttl: ttl, responses: make(map[string]cachedResponse)}
+}
+
+// responseCacheKey identifies the request, credentials and every setting
+// changing the response or its outcome included, it is empty for requests
+// which are not cached: not Cacheable, other methods, downloads, credentials
+// obtained at request time, headers set to the current time and requests
+// polling until a header converges. The ca and client certificates are
+// provider settings, each provider has its own cache.
+func responseCacheKey(rc *RequestConfig) string {
+	if !rc.Cacheable || rc.Method != http.MethodGet || rc.BodyWriter != nil || len(rc.CredentialCommand) > 0 || len(rc.Session) > 0 ||
+		rc.AuthRequest != nil || rc.Keystone != nil || rc.OAuth2 != nil || rc.AWSSigV4 != nil || len(rc.DateHeaders) > 0 || rc.RetryUntilHeader != nil {
+		return ""
+	}
+
+	ntlm := NTLMAuth{}
+	if rc.NTLM != nil {
+		ntlm = *rc.NTLM
+	}
+	return tokenCacheKey(requestHash(rc), rc.URL, rc.Credentials, rc.Username, rc.Password, rc.AuthType, rc.BearerToken, rc.APIKey, rc.APIKeyHeader,
+		ntlm.Domain, ntlm.Workstation,
+		strconv.FormatBool(rc.Insecure), strconv.FormatBool(rc.SkipTLSVerifyHostname), rc.CheckRevocation, rc.TLSRenegotiation,
+		rc.CompressBody, strconv.FormatBool(rc.UseSRVLookup), fmt.Sprint(rc.AllowedRemoteNetworks),
+		rc.ProxyURL, strconv.FormatBool(rc.NoProxy == nil), fmt.Sprintf("%q", rc.NoProxy),
+		rc.Timeout.String(), strconv.FormatInt(rc.MaxBodySize, 10), strconv.FormatBool(rc.HeadersOnly),
+		strconv.FormatBool(rc.PreflightHead), rc.PreflightContentType, strconv.FormatInt(rc.PreflightMaxContentLength, 10),
+		rc.SuccessWhen, fmt.Sprintf("%q", rc.Assertions),
+		strconv.Itoa(rc.RetryAttempts), rc.RetryMinDelay.String(), rc.RetryMaxDelay.String(), fmt.Sprint(rc.RetryOnStatusCodes))
+}
+
+// copyResponse returns a copy of the response whose headers and body can be
+// modified without altering the cached one
+func copyResponse(rsp *Response) *Response {
+	copied := *rsp
+	copied.Headers = rsp.Headers.Clone()
+	copied.Body = bytes.Clone(rsp.Body)
+	return &copied
+}
+
+// get returns a copy of the cached response, the cache is optional
+func (c *responseCache) get(key string, now time.Time) (*Response, bool) {
+	if c == nil || len(key) == 0 {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.responses[key]
+	if !ok || now.After(cached.expiresAt) {
+		return nil, false
+	}
+	return copyResponse(&cached.response), true
+}
+
+func (c *responseCache) set(key string, rsp *Response, now time.Time) {
+	if c == nil || len(key) == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.responses[key] = cachedResponse{response: *copyResponse(rsp), expiresAt: now.Add(c.ttl)}
+}
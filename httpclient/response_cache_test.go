@@ -0,0 +1,72 @@
+package httpclient
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestResponseCacheKey(t *testing.T) {
+	base := func() *RequestConfig {
+		return &RequestConfig{URL: "https://example.com/doc", Method: http.MethodGet, Cacheable: true}
+	}
+	_, network, _ := net.ParseCIDR("10.0.0.0/8")
+
+	// every setting changing the response or its outcome changes the key
+	settings := map[string]func(rc *RequestConfig){
+		"allowed_remote_networks":  func(rc *RequestConfig) { rc.AllowedRemoteNetworks = []*net.IPNet{network} },
+		"check_revocation":         func(rc *RequestConfig) { rc.CheckRevocation = revocationOCSP },
+		"skip_tls_verify_hostname": func(rc *RequestConfig) { rc.SkipTLSVerifyHostname = true },
+		"tls_renegotiation":        func(rc *RequestConfig) { rc.TLSRenegotiation = "once" },
+		"proxy_url":                func(rc *RequestConfig) { rc.ProxyURL = "http://proxy:3128" },
+		"no_proxy":                 func(rc *RequestConfig) { rc.NoProxy = []string{} },
+		"use_srv_lookup":           func(rc *RequestConfig) { rc.UseSRVLookup = true },
+		"preflight_head":           func(rc *RequestConfig) { rc.PreflightHead = true },
+		"timeout":                  func(rc *RequestConfig) { rc.Timeout = time.Minute },
+		"retry_attempts":           func(rc *RequestConfig) { rc.RetryAttempts = 3 },
+		"ntlm":                     func(rc *RequestConfig) { rc.NTLM = &NTLMAuth{Domain: "CORP"} },
+	}
+	key := responseCacheKey(base())
+	for name, set := range settings {
+		rc := base()
+		set(rc)
+		if other := responseCacheKey(rc); len(other) == 0 || other == key {
+			t.Errorf("%s: the key does not change", name)
+		}
+	}
+
+	// requests depending on the time they are sent are not cached
+	uncached := map[string]func(rc *RequestConfig){
+		"date_header":        func(rc *RequestConfig) { rc.DateHeaders = []DateHeader{{Name: "Date", Format: "http"}} },
+		"retry_until_header": func(rc *RequestConfig) { rc.RetryUntilHeader = &HeaderCondition{} },
+	}
+	for name, set := range uncached {
+		rc := base()
+		set(rc)
+		if other := responseCacheKey(rc); len(other) > 0 {
+			t.Errorf("%s: the request is cached", name)
+		}
+	}
+}
+
+func TestResponseCacheCopy(t *testing.T) {
+	cache := newResponseCache(time.Minute)
+	now := time.Now()
+	rsp := &Response{StatusCode: http.StatusOK, Headers: http.Header{"Set-Cookie": {"id=1"}}, Body: []byte("body")}
+	cache.set("key", rsp, now)
+	rsp.Headers.Del("Set-Cookie")
+
+	// changes to a cached response are not seen by the next requests
+	for i := 0; i < 2; i++ {
+		cached, ok := cache.get("key", now)
+		if !ok {
+			t.Fatalf("response not cached")
+		}
+		if cached.Headers.Get("Set-Cookie") != "id=1" || string(cached.Body) != "body" {
+			t.Errorf("cached response modified: %v %q", cached.Headers, cached.Body)
+		}
+		cached.Headers.Del("Set-Cookie")
+		cached.Body[0] = 'B'
+	}
+}
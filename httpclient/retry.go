@@ -0,0 +1,122 @@
+package httpclient
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// status codes retried when the request does not list any
+var defaultRetryStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// HeaderCondition is a response header expected to equal Value, or to match
+// Pattern when set
+type HeaderCondition struct {
+	Name    string
+	Value   string
+	Pattern *regexp.Regexp
+}
+
+// satisfied reports whether the response header meets the condition
+func (c *HeaderCondition) satisfied(rsp *Response) bool {
+	value := rsp.Headers.Get(c.Name)
+	if c.Pattern != nil {
+		return c.Pattern.MatchString(value)
+	}
+	return value == c.Value
+}
+
+// idempotentMethods can be sent again after a connection or timeout error
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+}
+
+// retryable reports whether the outcome of an attempt is transient, the
+// errors of non idempotent requests are only retried when allowed since
+// the server may have processed them
+func retryable(rc *RequestConfig, rsp *Response, err error) bool {
+	if rsp != nil && err == nil && rc.RetryUntilHeader != nil && !rc.RetryUntilHeader.satisfied(rsp) {
+		return true
+	}
+	if rsp != nil {
+		codes := rc.RetryOnStatusCodes
+		if len(codes) == 0 {
+			codes = defaultRetryStatusCodes
+		}
+		for _, code := range codes {
+			if rsp.StatusCode == code {
+				return true
+			}
+		}
+	}
+	method := strings.ToUpper(rc.Method)
+	if len(method) == 0 {
+		method = http.MethodGet
+	}
+	if err != nil && rsp == nil && (idempotentMethods[method] || rc.RetryNonIdempotent) {
+		switch errorCode(err) {
+		case errorCodeTimeout, errorCodeDNS, errorCodeConnection:
+			return true
+		}
+	}
+	return false
+}
+
+// retryDelay is the exponential backoff delay before the given retry, with
+// equal jitter so that parallel requests do not retry in lockstep, a longer
+// Retry-After from the server is honored up to the maximum delay
+func retryDelay(rc *RequestConfig, rsp *Response, retry int) time.Duration {
+	delay := rc.RetryMinDelay << (retry - 1)
+	if delay > rc.RetryMaxDelay || delay <= 0 {
+		delay = rc.RetryMaxDelay
+	}
+	delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+
+	if rsp != nil {
+		if seconds, err := strconv.Atoi(rsp.Headers.Get("Retry-After")); err == nil {
+			if retry_after := time.Duration(seconds) * time.Second; retry_after > delay {
+				delay = retry_after
+			}
+		}
+	}
+	if delay > rc.RetryMaxDelay {
+		delay = rc.RetryMaxDelay
+	}
+	return delay
+}
+
+// executeWithRetry sends the request, retried on transient failures
+func executeWithRetry(ctx context.Context, config *providerConfig, rc *RequestConfig) (*Response, error) {
+	for retry := 1; ; retry++ {
+		rsp, err := executeAuthRequest(ctx, config, rc)
+		if !retryable(rc, rsp, err) {
+			return rsp, err
+		}
+		if retry > rc.RetryAttempts {
+			if err == nil && rc.RetryUntilHeader != nil && !rc.RetryUntilHeader.satisfied(rsp) {
+				return rsp, newRequestError(errorCodeStatus, "response header %s = %q did not converge after %d attempts",
+					rc.RetryUntilHeader.Name, rsp.Headers.Get(rc.RetryUntilHeader.Name), retry)
+			}
+			return rsp, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return rsp, err
+		case <-time.After(retryDelay(rc, rsp, retry)):
+		}
+	}
+}
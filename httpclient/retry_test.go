@@ -0,0 +1,56 @@
+package httpclient
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryableMethods(t *testing.T) {
+	timeout := newRequestError(errorCodeTimeout, "request timed out")
+	for _, test := range []struct {
+		method         string
+		non_idempotent bool
+		expected       bool
+	}{
+		{http.MethodGet, false, true},
+		{"", false, true},
+		{http.MethodPut, false, true},
+		{http.MethodDelete, false, true},
+		{http.MethodPost, false, false},
+		{http.MethodPatch, false, false},
+		{http.MethodPost, true, true},
+	} {
+		rc := &RequestConfig{Method: test.method, RetryNonIdempotent: test.non_idempotent}
+		if retryable(rc, nil, timeout) != test.expected {
+			t.Errorf("%q with retry_non_idempotent %v: expected retryable %v", test.method, test.non_idempotent, test.expected)
+		}
+	}
+
+	// a status asking to retry is retried whatever the method
+	rsp := &Response{StatusCode: http.StatusServiceUnavailable, Headers: http.Header{}}
+	if !retryable(&RequestConfig{Method: http.MethodPost}, rsp, nil) {
+		t.Error("expected a 503 response to be retried")
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	rc := &RequestConfig{RetryMinDelay: 100 * time.Millisecond, RetryMaxDelay: 10 * time.Second}
+
+	// exponential backoff with equal jitter
+	for retry, max := range map[int]time.Duration{1: 100 * time.Millisecond, 3: 400 * time.Millisecond, 20: 10 * time.Second} {
+		if delay := retryDelay(rc, nil, retry); delay < max/2 || delay > max {
+			t.Errorf("retry %d: delay %s not in [%s, %s]", retry, delay, max/2, max)
+		}
+	}
+
+	// a longer Retry-After is honored up to the maximum delay
+	rsp := &Response{Headers: http.Header{"Retry-After": []string{"2"}}}
+	if delay := retryDelay(rc, rsp, 1); delay != 2*time.Second {
+		t.Errorf("delay is %s, expected the 2s of Retry-After", delay)
+	}
+	rsp.Headers.Set("Retry-After", "60")
+	if delay := retryDelay(rc, rsp, 1); delay != 10*time.Second {
+		t.Errorf("delay is %s, expected the maximum delay", delay)
+	}
+}
@@ -0,0 +1,187 @@
+package httpclient
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	revocationOff  = "off"
+	revocationOCSP = "ocsp"
+	revocationCRL  = "crl"
+)
+
+// revocation statuses exported through the revocation_status attribute
+const (
+	revocationStatusUnchecked = "unchecked"
+	revocationStatusGood      = "good"
+	revocationStatusRevoked   = "revoked"
+	revocationStatusUnknown   = "unknown"
+)
+
+// revocationClockSkew tolerates clocks ahead of the responder one
+const revocationClockSkew = 5 * time.Minute
+
+// checkRevocation checks the revocation status of the server certificate
+// with the given mode, a stapled ocsp response is preferred when available.
+// Stale responses and unknown statuses fail the check.
+func checkRevocation(mode string, cs tls.ConnectionState, client *http.Client) (string, error) {
+	if mode == revocationOff || mode == "" {
+		return revocationStatusUnchecked, nil
+	}
+
+	leaf, issuer, err := leafAndIssuer(cs)
+	if err != nil {
+		return revocationStatusUnknown, &RequestError{Code: errorCodeTLSVerify, Err: err}
+	}
+
+	var status string
+	switch mode {
+	case revocationOCSP:
+		status, err = checkOCSP(client, leaf, issuer, cs.OCSPResponse, time.Now())
+	case revocationCRL:
+		status, err = checkCRL(client, leaf, issuer, time.Now())
+	default:
+		return revocationStatusUnknown, fmt.Errorf("unsupported revocation check mode %q", mode)
+	}
+	if err != nil {
+		return revocationStatusUnknown, &RequestError{Code: errorCodeTLSVerify, Err: err}
+	}
+	if status == revocationStatusRevoked {
+		return status, newRequestError(errorCodeTLSVerify, "tls: certificate of %s has been revoked", leaf.Subject.CommonName)
+	}
+	return status, nil
+}
+
+// leafAndIssuer returns the server certificate and the certificate which signed it
+func leafAndIssuer(cs tls.ConnectionState) (*x509.Certificate, *x509.Certificate, error) {
+	if len(cs.VerifiedChains) > 0 && len(cs.VerifiedChains[0]) > 1 {
+		return cs.VerifiedChains[0][0], cs.VerifiedChains[0][1], nil
+	}
+	if len(cs.PeerCertificates) > 1 {
+		return cs.PeerCertificates[0], cs.PeerCertificates[1], nil
+	}
+	return nil, nil, errors.New("tls: unable to find the issuer of the server certificate for revocation checking")
+}
+
+func checkOCSP(client *http.Client, leaf, issuer *x509.Certificate, stapled []byte, now time.Time) (string, error) {
+	raw := stapled
+	if len(raw) == 0 {
+		if len(leaf.OCSPServer) == 0 {
+			return "", errors.New("tls: server certificate has no OCSP responder and no stapled response")
+		}
+
+		req, err := ocsp.CreateRequest(leaf, issuer, &ocsp.RequestOptions{Hash: crypto.SHA1})
+		if err != nil {
+			return "", err
+		}
+
+		raw, err = fetchRevocationData(client, http.MethodPost, leaf.OCSPServer[0], req, "application/ocsp-request")
+		if err != nil {
+			return "", err
+		}
+	}
+
+	rsp, err := ocsp.ParseResponseForCert(raw, leaf, issuer)
+	if err != nil {
+		return "", err
+	}
+	// a replayed stapled response must not prove the certificate is good
+	if err := checkRevocationValidity("OCSP response", rsp.ThisUpdate, rsp.NextUpdate, now); err != nil {
+		return "", err
+	}
+
+	switch rsp.Status {
+	case ocsp.Good:
+		return revocationStatusGood, nil
+	case ocsp.Revoked:
+		return revocationStatusRevoked, nil
+	default:
+		return revocationStatusUnknown, errors.New("tls: OCSP responder does not know the server certificate")
+	}
+}
+
+func checkCRL(client *http.Client, leaf, issuer *x509.Certificate, now time.Time) (string, error) {
+	if len(leaf.CRLDistributionPoints) == 0 {
+		return "", errors.New("tls: server certificate has no CRL distribution point")
+	}
+
+	raw, err := fetchRevocationData(client, http.MethodGet, leaf.CRLDistributionPoints[0], nil, "")
+	if err != nil {
+		return "", err
+	}
+
+	crl, err := x509.ParseRevocationList(raw)
+	if err != nil {
+		return "", err
+	}
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		return "", err
+	}
+	if err := checkRevocationValidity("CRL", crl.ThisUpdate, crl.NextUpdate, now); err != nil {
+		return "", err
+	}
+
+	for _, entry := range crl.RevokedCertificateEntries {
+		if entry.SerialNumber.Cmp(leaf.SerialNumber) == 0 {
+			return revocationStatusRevoked, nil
+		}
+	}
+	return revocationStatusGood, nil
+}
+
+// checkRevocationValidity rejects the revocation data issued in the future
+// or past its next update, no next update means it is always current
+func checkRevocationValidity(kind string, this_update, next_update time.Time, now time.Time) error {
+	if this_update.After(now.Add(revocationClockSkew)) {
+		return fmt.Errorf("tls: %s is not valid before %s", kind, this_update.Format(time.RFC3339))
+	}
+	if !next_update.IsZero() && now.After(next_update) {
+		return fmt.Errorf("tls: %s expired at %s", kind, next_update.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// revocationClient downloads the ocsp responses and crls through the proxy
+// of the request, trusting the certificate authorities of the provider
+func revocationClient(rc *RequestConfig, config *providerConfig) *http.Client {
+	tr := &http.Transport{
+		Proxy:           proxyFunc(rc, config),
+		TLSClientConfig: &tls.Config{RootCAs: config.rootCAs},
+	}
+	if config.dnsCache != nil {
+		tr.DialContext = config.dnsCache.DialContext
+	}
+	return &http.Client{Transport: tr, Timeout: 10 * time.Second}
+}
+
+// fetchRevocationData downloads an ocsp response or a crl
+func fetchRevocationData(client *http.Client, method, url string, body []byte, contentType string) ([]byte, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	r, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d from %s", r.StatusCode, url)
+	}
+	return io.ReadAll(r.Body)
+}
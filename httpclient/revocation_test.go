@@ -0,0 +1,120 @@
+package httpclient
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// testRevocationChain returns a ca and a server certificate it issued
+func testRevocationChain(t *testing.T, crl_url string) (*x509.Certificate, crypto.Signer, *x509.Certificate) {
+	t.Helper()
+	ca_key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ca_template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	ca_der, err := x509.CreateCertificate(rand.Reader, ca_template, ca_template, ca_key.Public(), ca_key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, _ := x509.ParseCertificate(ca_der)
+
+	leaf_key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	leaf_template := &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "server"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		CRLDistributionPoints: []string{crl_url},
+	}
+	leaf_der, err := x509.CreateCertificate(rand.Reader, leaf_template, ca, leaf_key.Public(), ca_key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, _ := x509.ParseCertificate(leaf_der)
+	return ca, ca_key, leaf
+}
+
+func TestCheckOCSPStapled(t *testing.T) {
+	ca, ca_key, leaf := testRevocationChain(t, "")
+	now := time.Now()
+
+	staple := func(status int, this_update, next_update time.Time) []byte {
+		raw, err := ocsp.CreateResponse(ca, ca, ocsp.Response{
+			Status:       status,
+			SerialNumber: leaf.SerialNumber,
+			ThisUpdate:   this_update,
+			NextUpdate:   next_update,
+		}, ca_key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+
+	status, err := checkOCSP(http.DefaultClient, leaf, ca, staple(ocsp.Good, now.Add(-time.Hour), now.Add(time.Hour)), now)
+	if err != nil || status != revocationStatusGood {
+		t.Errorf("status is %s, %v, expected good", status, err)
+	}
+
+	// a replayed response past its next update is not a proof
+	_, err = checkOCSP(http.DefaultClient, leaf, ca, staple(ocsp.Good, now.Add(-48*time.Hour), now.Add(-24*time.Hour)), now)
+	if err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("unexpected error for a stale response: %v", err)
+	}
+
+	// the responder does not know the certificate
+	_, err = checkOCSP(http.DefaultClient, leaf, ca, staple(ocsp.Unknown, now.Add(-time.Hour), now.Add(time.Hour)), now)
+	if err == nil {
+		t.Error("expected an error for an unknown status")
+	}
+}
+
+func TestCheckCRLExpired(t *testing.T) {
+	var crl []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(crl)
+	}))
+	defer server.Close()
+
+	ca, ca_key, leaf := testRevocationChain(t, server.URL)
+	now := time.Now()
+	issue := func(this_update, next_update time.Time) []byte {
+		raw, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+			Number:     big.NewInt(1),
+			ThisUpdate: this_update,
+			NextUpdate: next_update,
+		}, ca, ca_key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+
+	crl = issue(now.Add(-time.Hour), now.Add(time.Hour))
+	if status, err := checkCRL(server.Client(), leaf, ca, now); err != nil || status != revocationStatusGood {
+		t.Errorf("status is %s, %v, expected good", status, err)
+	}
+
+	crl = issue(now.Add(-48*time.Hour), now.Add(-24*time.Hour))
+	if _, err := checkCRL(server.Client(), leaf, ca, now); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("unexpected error for an expired crl: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package httpclient
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// scrubRule replaces the matches of a pattern
+type scrubRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// scrubber removes sensitive data from the values written to the state,
+// rules are applied in the order of their patterns
+type scrubber []scrubRule
+
+// newScrubber compiles the patterns of a regex to replacement map, the
+// patterns are checked by the schema validation
+func newScrubber(patterns map[string]interface{}) scrubber {
+	var s scrubber
+	for pattern, replacement := range patterns {
+		s = append(s, scrubRule{pattern: regexp.MustCompile(pattern), replacement: replacement.(string)})
+	}
+	sort.Slice(s, func(i, j int) bool { return s[i].pattern.String() < s[j].pattern.String() })
+	return s
+}
+
+func (s scrubber) apply(value string) string {
+	for _, rule := range s {
+		value = rule.pattern.ReplaceAllString(value, rule.replacement)
+	}
+	return value
+}
+
+// applyMap scrubs the values of the map in place
+func (s scrubber) applyMap(values map[string]string) map[string]string {
+	for key, value := range values {
+		values[key] = s.apply(value)
+	}
+	return values
+}
+
+// validateScrubPatterns checks the keys of the scrub_patterns map are valid
+// regular expressions
+func validateScrubPatterns(v interface{}, k string) ([]string, []error) {
+	var errs []error
+	for pattern := range v.(map[string]interface{}) {
+		if _, err := regexp.Compile(pattern); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid pattern %q: %s", k, pattern, err))
+		}
+	}
+	return nil, errs
+}
@@ -0,0 +1,41 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"sync"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// sessionJars keeps the cookie jars of the sessions for the provider run,
+// cookies are only kept in memory and never written to the state
+type sessionJars struct {
+	mu   sync.Mutex
+	jars map[string]http.CookieJar
+}
+
+func newSessionJars() *sessionJars {
+	return &sessionJars{jars: make(map[string]http.CookieJar)}
+}
+
+// jar returns the cookie jar of a session, created on first use
+func (s *sessionJars) jar(name string) http.CookieJar {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jar, ok := s.jars[name]
+	if !ok {
+		// the public suffix list prevents cookies set for a whole tld
+		jar, _ = cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+		s.jars[name] = jar
+	}
+	return jar
+}
+
+// reset drops the cookies of a session
+func (s *sessionJars) reset(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.jars, name)
+}
@@ -0,0 +1,55 @@
+package httpclient
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// algorithms accepted by response_signature_algorithm
+const (
+	signatureHMACSHA256 = "hmac-sha256"
+	signatureEd25519    = "ed25519"
+)
+
+// signBody returns a base64 detached signature of the body, the key is the
+// hmac secret or a PEM encoded (PKCS #8) ed25519 private key
+func signBody(algorithm, key string, body []byte) (string, error) {
+	switch algorithm {
+	case signatureHMACSHA256:
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write(body)
+		return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+
+	case signatureEd25519:
+		private_key, err := parseEd25519Key(key)
+		if err != nil {
+			return "", err
+		}
+		return base64.StdEncoding.EncodeToString(ed25519.Sign(private_key, body)), nil
+	}
+	return "", fmt.Errorf("unsupported signature algorithm %q", algorithm)
+}
+
+func parseEd25519Key(key string) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(key))
+	if block == nil {
+		return nil, errors.New("response_signature_key: invalid PEM private key")
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("response_signature_key: %w", err)
+	}
+
+	private_key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("response_signature_key: not an ed25519 private key")
+	}
+	return private_key, nil
+}
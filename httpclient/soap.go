@@ -0,0 +1,50 @@
+package httpclient
+
+import (
+	"fmt"
+)
+
+// soap versions of the soap_envelope attribute
+const (
+	soapVersion11 = "1.1"
+	soapVersion12 = "1.2"
+)
+
+// soapNamespaces are the envelope namespaces of the soap versions
+var soapNamespaces = map[string]string{
+	soapVersion11: "http://schemas.xmlsoap.org/soap/envelope/",
+	soapVersion12: "http://www.w3.org/2003/05/soap-envelope",
+}
+
+// soapEnvelope wraps the body in the envelope of the soap version
+func soapEnvelope(version string, body []byte) []byte {
+	return []byte(fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>`+
+		`<soap:Envelope xmlns:soap="%s"><soap:Body>%s</soap:Body></soap:Envelope>`, soapNamespaces[version], body))
+}
+
+// soapContentType returns the Content-Type of the soap version, soap 1.2
+// carries the action in it instead of the SOAPAction header
+func soapContentType(version string, action string) string {
+	if version == soapVersion12 {
+		if len(action) > 0 {
+			return fmt.Sprintf(`application/soap+xml; charset=utf-8; action="%s"`, action)
+		}
+		return "application/soap+xml; charset=utf-8"
+	}
+	return "text/xml; charset=utf-8"
+}
+
+// soapFault returns the reason of a soap fault of the response, empty when
+// the response is not a fault
+func soapFault(doc *xmlNode) string {
+	// soap 1.1 faultstring, soap 1.2 Reason/Text
+	for _, path := range []string{"/Envelope/Body/Fault/faultstring", "/Envelope/Body/Fault/Reason/Text"} {
+		if value, found, _ := xpathLookup(doc, path); found {
+			return value
+		}
+	}
+	if _, found, _ := xpathLookup(doc, "/Envelope/Body/Fault"); found {
+		return "unknown fault"
+	}
+	return ""
+}
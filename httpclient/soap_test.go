@@ -0,0 +1,115 @@
+package httpclient
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSOAPFault(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		fault string
+	}{
+		{
+			name: "soap 1.1 fault",
+			body: `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>` +
+				`<soap:Fault><faultcode>soap:Server</faultcode><faultstring>Order not found</faultstring></soap:Fault>` +
+				`</soap:Body></soap:Envelope>`,
+			fault: "Order not found",
+		},
+		{
+			name: "soap 1.2 fault",
+			body: `<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope"><env:Body>` +
+				`<env:Fault><env:Code><env:Value>env:Sender</env:Value></env:Code>` +
+				`<env:Reason><env:Text xml:lang="en">Invalid order id</env:Text></env:Reason></env:Fault>` +
+				`</env:Body></env:Envelope>`,
+			fault: "Invalid order id",
+		},
+		{
+			name:  "fault without reason",
+			body:  `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><soap:Fault/></soap:Body></soap:Envelope>`,
+			fault: "unknown fault",
+		},
+		{
+			name:  "response",
+			body:  `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><Result>ok</Result></soap:Body></soap:Envelope>`,
+			fault: "",
+		},
+	}
+	for _, tt := range tests {
+		doc, err := parseXML([]byte(tt.body))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", tt.name, err)
+		}
+		if fault := soapFault(doc); fault != tt.fault {
+			t.Errorf("%s: fault is %q, expected %q", tt.name, fault, tt.fault)
+		}
+	}
+}
+
+func TestSOAPEnvelope(t *testing.T) {
+	for version, namespace := range soapNamespaces {
+		envelope := soapEnvelope(version, []byte(`<m:GetOrder xmlns:m="urn:example:orders"><m:Id>1</m:Id></m:GetOrder>`))
+		if !strings.Contains(string(envelope), `xmlns:soap="`+namespace+`"`) {
+			t.Errorf("soap %s: envelope without the namespace %s: %s", version, namespace, envelope)
+		}
+
+		// the body is found back in the envelope
+		doc, err := parseXML(envelope)
+		if err != nil {
+			t.Fatalf("soap %s: invalid envelope: %s", version, err)
+		}
+		if value, found, _ := xpathLookup(doc, "/Envelope/Body/GetOrder/Id"); !found || value != "1" {
+			t.Errorf("soap %s: body not found in the envelope: %s", version, envelope)
+		}
+	}
+}
+
+func TestSOAPContentType(t *testing.T) {
+	tests := []struct {
+		version      string
+		action       string
+		content_type string
+	}{
+		{version: soapVersion11, action: "urn:GetOrder", content_type: "text/xml; charset=utf-8"},
+		{version: soapVersion12, action: "urn:GetOrder", content_type: `application/soap+xml; charset=utf-8; action="urn:GetOrder"`},
+		{version: soapVersion12, action: "", content_type: "application/soap+xml; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		if content_type := soapContentType(tt.version, tt.action); content_type != tt.content_type {
+			t.Errorf("soap %s: content type is %q, expected %q", tt.version, content_type, tt.content_type)
+		}
+	}
+}
+
+func TestExtractXMLFields(t *testing.T) {
+	fault := `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>` +
+		`<soap:Fault><faultcode>soap:Client</faultcode><faultstring>Access denied</faultstring></soap:Fault>` +
+		`</soap:Body></soap:Envelope>`
+
+	// the fault of a soap response is reported
+	extracted := make(map[string]string)
+	soap_fault, err := extractXMLFields(map[string]interface{}{"code": "//Fault/faultcode"}, []byte(fault), extracted, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if soap_fault != "Access denied" || extracted["code"] != "soap:Client" {
+		t.Errorf("fault is %q and code %q", soap_fault, extracted["code"])
+	}
+
+	// a missing node fails the request
+	_, err = extractXMLFields(map[string]interface{}{"id": "//Order/Id"}, []byte(fault), make(map[string]string), true)
+	if errorCode(err) != errorCodeBodyDecode {
+		t.Errorf("error code is %s, expected %s", errorCode(err), errorCodeBodyDecode)
+	}
+
+	// non xml responses are only an error when fields are extracted
+	if soap_fault, err := extractXMLFields(nil, []byte(`{"error":"html"}`), extracted, true); err != nil || len(soap_fault) > 0 {
+		t.Errorf("unexpected fault %q or error %v", soap_fault, err)
+	}
+	_, err = extractXMLFields(map[string]interface{}{"id": "//Id"}, []byte(`{"id":1}`), make(map[string]string), false)
+	if errorCode(err) != errorCodeBodyDecode {
+		t.Errorf("error code is %s, expected %s", errorCode(err), errorCodeBodyDecode)
+	}
+}
@@ -0,0 +1,80 @@
+package httpclient
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// runStats aggregates the requests sent by the provider during the run
+type runStats struct {
+	mu            sync.Mutex
+	requests      int
+	errors        map[string]int
+	responseBytes int64
+	latencies     []time.Duration
+}
+
+func newRunStats() *runStats {
+	return &runStats{errors: make(map[string]int)}
+}
+
+// record accounts for one request, stats are optional
+func (s *runStats) record(rsp *Response, err error, latency time.Duration) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+	s.latencies = append(s.latencies, latency)
+	if rsp != nil {
+		s.responseBytes += rsp.BodySize
+	}
+	if err != nil {
+		s.errors[errorCode(err)]++
+	}
+}
+
+// runStatsSnapshot is a copy of the stats at a point in time
+type runStatsSnapshot struct {
+	requests      int
+	errors        map[string]int
+	errorCount    int
+	responseBytes int64
+	latencies     []time.Duration
+}
+
+func (s *runStats) snapshot() runStatsSnapshot {
+	snapshot := runStatsSnapshot{errors: make(map[string]int)}
+	if s == nil {
+		return snapshot
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot.requests = s.requests
+	snapshot.responseBytes = s.responseBytes
+	for code, count := range s.errors {
+		snapshot.errors[code] = count
+		snapshot.errorCount += count
+	}
+	snapshot.latencies = append(snapshot.latencies, s.latencies...)
+	sort.Slice(snapshot.latencies, func(i, j int) bool { return snapshot.latencies[i] < snapshot.latencies[j] })
+	return snapshot
+}
+
+// percentile returns the latency below which the given percentage of the
+// requests fall, using the nearest-rank method
+func (s runStatsSnapshot) percentile(p float64) time.Duration {
+	if len(s.latencies) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(s.latencies))))
+	if rank < 1 {
+		rank = 1
+	}
+	return s.latencies[rank-1]
+}
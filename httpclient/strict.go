@@ -0,0 +1,72 @@
+package httpclient
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// strictViolations lists the arguments of the request which are set but
+// have no effect, they are only reported when the provider is strict
+func strictViolations(d *schema.ResourceData) []string {
+	var violations []string
+	headers := d.Get("request_headers").(map[string]interface{})
+	has_header := func(name string) bool {
+		for key := range headers {
+			if strings.EqualFold(key, name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// most servers ignore the body of GET and HEAD requests
+	method := strings.ToUpper(d.Get("request_method").(string))
+	if method == http.MethodGet || method == http.MethodHead {
+		for _, name := range []string{"request_body", "request_body_form", "request_body_multipart", "json_patch"} {
+			if _, ok := d.GetOk(name); ok {
+				violations = append(violations, name+" is set on a "+method+" request")
+			}
+		}
+	}
+
+	if d.Get("retry_attempts").(int) == 0 {
+		for _, name := range []string{"retry_on_status_codes", "retry_until_header"} {
+			if _, ok := d.GetOk(name); ok {
+				violations = append(violations, name+" is set without retry_attempts")
+			}
+		}
+	}
+
+	if !d.Get("preflight_head").(bool) {
+		for _, name := range []string{"preflight_content_type", "preflight_max_content_length"} {
+			if _, ok := d.GetOk(name); ok {
+				violations = append(violations, name+" is set without preflight_head")
+			}
+		}
+	}
+
+	if _, ok := d.GetOk("accept"); ok && has_header("Accept") {
+		violations = append(violations, "accept is ignored because request_headers sets Accept")
+	}
+	if name := d.Get("api_version_header").(string); len(name) > 0 && has_header(name) {
+		violations = append(violations, "api_version is ignored because request_headers sets "+name)
+	}
+
+	return violations
+}
+
+// strictDiags turns the violations into errors
+func strictDiags(violations []string) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, violation := range violations {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Ineffective argument in strict mode",
+			Detail:   violation + ", remove it or disable strict in the provider configuration.",
+		})
+	}
+	return diags
+}
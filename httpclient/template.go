@@ -0,0 +1,58 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// templatePlaceholder matches {{ name }} placeholders, names may contain dots
+var templatePlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// templateName validates the names usable in placeholders
+var templateName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// renderTemplate replaces the placeholders with the given values. The other
+// placeholders are left as is, so that bodies can carry mustache or go
+// templates, except the unknown values of a known step, such as a typo in
+// {{login.tokn}}, which are an error rather than being sent as is
+func renderTemplate(s string, values map[string]string) (string, error) {
+	steps := make(map[string]bool)
+	for name := range values {
+		if step, _, ok := strings.Cut(name, "."); ok {
+			steps[step] = true
+		}
+	}
+
+	var missing []string
+	out := templatePlaceholder.ReplaceAllStringFunc(s, func(match string) string {
+		name := templatePlaceholder.FindStringSubmatch(match)[1]
+		value, ok := values[name]
+		if !ok {
+			if step, _, ok := strings.Cut(name, "."); ok && steps[step] {
+				missing = append(missing, name)
+			}
+			return match
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("unknown template value %q", missing[0])
+	}
+	return out, nil
+}
+
+// jsonValueString converts a decoded json value to a string, scalars are
+// returned as is and objects or arrays are encoded back to json
+func jsonValueString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
@@ -0,0 +1,31 @@
+package httpclient
+
+import (
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	values := map[string]string{"login.token": "abc", "id": "42"}
+
+	for _, test := range []struct {
+		input    string
+		expected string
+	}{
+		{"/items/{{id}}?token={{ login.token }}", "/items/42?token=abc"},
+		// placeholders of an uploaded template are sent as is
+		{`{"template":"Hello {{name}}, {{ .Title }} {{#items}}{{/items}}"}`, `{"template":"Hello {{name}}, {{ .Title }} {{#items}}{{/items}}"}`},
+		{"{{user.name}} {{id}}", "{{user.name}} 42"},
+	} {
+		out, err := renderTemplate(test.input, values)
+		if err != nil {
+			t.Errorf("%s: %s", test.input, err)
+		} else if out != test.expected {
+			t.Errorf("%s: rendered %q, expected %q", test.input, out, test.expected)
+		}
+	}
+
+	// an unknown value of a known step is a typo
+	if _, err := renderTemplate("Bearer {{login.tokn}}", values); err == nil {
+		t.Error("expected an error for an unknown value of a known step")
+	}
+}
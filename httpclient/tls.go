@@ -0,0 +1,118 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+)
+
+// renegotiation modes accepted by the tls_renegotiation attribute
+var tlsRenegotiationModes = map[string]tls.RenegotiationSupport{
+	"never":  tls.RenegotiateNever,
+	"once":   tls.RenegotiateOnceAsClient,
+	"freely": tls.RenegotiateFreelyAsClient,
+}
+
+// tlsInfo collects what happened during the tls handshake
+type tlsInfo struct {
+	revocationStatus string
+	// clientCertRequested is set when the server asked for a client
+	// certificate while none is configured
+	clientCertRequested bool
+}
+
+// clientAuthAlerts are the errors of the alerts sent by servers rejecting
+// the client certificate, or its absence
+var clientAuthAlerts = []string{"tls: bad certificate", "tls: certificate required"}
+
+// isClientAuthAlert reports whether the server rejected the client
+// certificate during the handshake
+func isClientAuthAlert(err error) bool {
+	var op_err *net.OpError
+	if !errors.As(err, &op_err) || op_err.Op != "remote error" {
+		return false
+	}
+	for _, alert := range clientAuthAlerts {
+		if op_err.Err.Error() == alert {
+			return true
+		}
+	}
+	return false
+}
+
+// newTLSConfig builds the client tls configuration from the request settings
+func newTLSConfig(rc *RequestConfig, config *providerConfig, info *tlsInfo) *tls.Config {
+	cfg := &tls.Config{
+		InsecureSkipVerify: rc.Insecure,
+		Renegotiation:      tlsRenegotiationModes[rc.TLSRenegotiation],
+		KeyLogWriter:       config.keyLogWriter,
+		ClientSessionCache: config.sessionCache,
+		RootCAs:            config.rootCAs,
+	}
+
+	// the client certificate is read on each handshake
+	if config.clientIdentity != nil {
+		cfg.GetClientCertificate = config.clientIdentity.GetClientCertificate
+	}
+	if config.svidSource != nil {
+		cfg.GetClientCertificate = tlsconfig.GetClientCertificate(config.svidSource)
+	}
+
+	// detect client certificate requests to explain handshake failures
+	if cfg.GetClientCertificate == nil {
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			info.clientCertRequested = true
+			return &tls.Certificate{}, nil
+		}
+	}
+
+	// verify the certificate chain but not the hostname
+	if !cfg.InsecureSkipVerify && rc.SkipTLSVerifyHostname {
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyConnection = verifyChainOnly(cfg)
+	}
+
+	// check the revocation status once the chain is verified
+	info.revocationStatus = revocationStatusUnchecked
+	if mode := rc.CheckRevocation; mode != revocationOff && mode != "" {
+		verify := cfg.VerifyConnection
+		client := revocationClient(rc, config)
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			if verify != nil {
+				if err := verify(cs); err != nil {
+					return err
+				}
+			}
+
+			status, err := checkRevocation(mode, cs, client)
+			info.revocationStatus = status
+			return err
+		}
+	}
+
+	return cfg
+}
+
+// verifyChainOnly returns a VerifyConnection callback checking the peer
+// certificate chain against the configured (or system) roots, ignoring the name
+func verifyChainOnly(cfg *tls.Config) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return errors.New("tls: server did not present any certificate")
+		}
+
+		opts := x509.VerifyOptions{
+			Roots:         cfg.RootCAs,
+			Intermediates: x509.NewCertPool(),
+		}
+		for _, cert := range cs.PeerCertificates[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+
+		_, err := cs.PeerCertificates[0].Verify(opts)
+		return err
+	}
+}
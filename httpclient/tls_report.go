@@ -0,0 +1,136 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// TLSReport describes the server certificate, it is computed whatever the
+// verification settings so that policies can be asserted on it
+type TLSReport struct {
+	// Verified is whether the chain verifies against the trusted roots
+	Verified    bool
+	VerifyError string
+	// HostnameMatch is whether the certificate is valid for the url host
+	HostnameMatch   bool
+	Subject         string
+	Issuer          string
+	NotAfter        time.Time
+	DaysUntilExpiry int
+	// VerifiedChains are the chains built to a trusted root, as subjects
+	// joined from the leaf to the root
+	VerifiedChains []string
+}
+
+// newTLSReport verifies the peer certificates of the connection against roots,
+// the system ones when nil
+func newTLSReport(cs *tls.ConnectionState, host string, roots *x509.CertPool, now time.Time) *TLSReport {
+	if cs == nil || len(cs.PeerCertificates) == 0 {
+		return nil
+	}
+	leaf := cs.PeerCertificates[0]
+
+	report := &TLSReport{
+		HostnameMatch:   leaf.VerifyHostname(host) == nil,
+		Subject:         leaf.Subject.String(),
+		Issuer:          leaf.Issuer.String(),
+		NotAfter:        leaf.NotAfter,
+		DaysUntilExpiry: int(math.Floor(leaf.NotAfter.Sub(now).Hours() / 24)),
+	}
+
+	// the handshake may have skipped the verification, verify again
+	chains := cs.VerifiedChains
+	if len(chains) == 0 {
+		opts := x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: x509.NewCertPool(),
+			CurrentTime:   now,
+		}
+		for _, cert := range cs.PeerCertificates[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+
+		var err error
+		chains, err = leaf.Verify(opts)
+		if err != nil {
+			report.VerifyError = err.Error()
+		}
+	}
+
+	report.Verified = len(chains) > 0
+	for _, chain := range chains {
+		var subjects []string
+		for _, cert := range chain {
+			subjects = append(subjects, cert.Subject.String())
+		}
+		report.VerifiedChains = append(report.VerifiedChains, strings.Join(subjects, " > "))
+	}
+	return report
+}
+
+// flattenTLSReport converts the report for the tls_report attribute
+func flattenTLSReport(report *TLSReport) []interface{} {
+	if report == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"verified":          report.Verified,
+		"verify_error":      report.VerifyError,
+		"hostname_match":    report.HostnameMatch,
+		"subject":           report.Subject,
+		"issuer":            report.Issuer,
+		"not_after":         report.NotAfter.UTC().Format(time.RFC3339),
+		"days_until_expiry": report.DaysUntilExpiry,
+		"verified_chains":   report.VerifiedChains,
+	}}
+}
+
+// tlsReportSchema is the computed tls_report attribute
+func tlsReportSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Computed: true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"verified": {
+					Type:     schema.TypeBool,
+					Computed: true,
+				},
+				"verify_error": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"hostname_match": {
+					Type:     schema.TypeBool,
+					Computed: true,
+				},
+				"subject": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"issuer": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"not_after": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"days_until_expiry": {
+					Type:     schema.TypeInt,
+					Computed: true,
+				},
+				"verified_chains": {
+					Type:     schema.TypeList,
+					Computed: true,
+					Elem:     &schema.Schema{Type: schema.TypeString},
+				},
+			},
+		},
+	}
+}
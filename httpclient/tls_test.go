@@ -0,0 +1,57 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientAuthErrorCode(t *testing.T) {
+	tests := []struct {
+		name       string
+		clientAuth tls.ClientAuthType
+		handler    http.HandlerFunc
+		code       string
+	}{
+		{
+			name:       "certificate required",
+			clientAuth: tls.RequireAnyClientCert,
+			handler:    func(w http.ResponseWriter, r *http.Request) {},
+			code:       errorCodeClientAuth,
+		},
+		{
+			// the certificate is optional, the connection fails for
+			// another reason
+			name:       "connection closed",
+			clientAuth: tls.RequestClientCert,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				conn, _, _ := w.(http.Hijacker).Hijack()
+				conn.Close()
+			},
+			code: errorCodeUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewUnstartedServer(tt.handler)
+			server.TLS = &tls.Config{ClientAuth: tt.clientAuth}
+			server.Config.ErrorLog = log.New(io.Discard, "", 0)
+			server.StartTLS()
+			defer server.Close()
+
+			rc := &RequestConfig{URL: server.URL, Method: http.MethodGet, Insecure: true}
+			_, err := ExecuteRequest(context.Background(), newTestConfig(), rc)
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			if code := errorCode(err); code != tt.code {
+				t.Errorf("error code is %s, expected %s: %s", code, tt.code, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package httpclient
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenExpiryMargin renews tokens this long before they expire
+const tokenExpiryMargin = time.Minute
+
+// tokenCache keeps the tokens obtained by the authentication helpers for
+// the provider run, keyed by a hash of what they were obtained with
+type tokenCache struct {
+	mu     sync.Mutex
+	tokens map[string]cachedToken
+}
+
+type cachedToken struct {
+	value     string
+	expiresAt time.Time
+}
+
+func newTokenCache() *tokenCache {
+	return &tokenCache{tokens: make(map[string]cachedToken)}
+}
+
+// tokenCacheKey hashes the parts identifying a token, secrets included, so
+// that they are not kept in memory in clear
+func tokenCacheKey(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// get returns the token unless missing or about to expire, the cache is optional
+func (c *tokenCache) get(key string, now time.Time) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	token, ok := c.tokens[key]
+	if !ok || now.Add(tokenExpiryMargin).After(token.expiresAt) {
+		return "", false
+	}
+	return token.value, true
+}
+
+func (c *tokenCache) set(key string, value string, expiresAt time.Time) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.tokens[key] = cachedToken{value: value, expiresAt: expiresAt}
+}
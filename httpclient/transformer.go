@@ -0,0 +1,105 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ResponseTransformer rewrites the body of a response before the data
+// source extracts and stores it, params are the ones of the
+// response_transformer block
+type ResponseTransformer func(ctx context.Context, rsp *Response, params map[string]string) ([]byte, error)
+
+var (
+	transformersMu sync.RWMutex
+	transformers   = make(map[string]ResponseTransformer)
+)
+
+// RegisterResponseTransformer makes a transformer available to the
+// response_transformer blocks under the given name. Builds embedding the
+// provider call it from an init function, it panics when the name is
+// already registered.
+func RegisterResponseTransformer(name string, transformer ResponseTransformer) {
+	transformersMu.Lock()
+	defer transformersMu.Unlock()
+
+	if transformer == nil {
+		panic("httpclient: RegisterResponseTransformer transformer is nil")
+	}
+	if _, dup := transformers[name]; dup {
+		panic("httpclient: RegisterResponseTransformer called twice for transformer " + name)
+	}
+	transformers[name] = transformer
+}
+
+// responseTransformer returns the transformer registered under the name
+func responseTransformer(name string) (ResponseTransformer, bool) {
+	transformersMu.RLock()
+	defer transformersMu.RUnlock()
+
+	transformer, ok := transformers[name]
+	return transformer, ok
+}
+
+// transformerNames returns the names of the registered transformers
+func transformerNames() []string {
+	transformersMu.RLock()
+	defer transformersMu.RUnlock()
+
+	names := make([]string, 0, len(transformers))
+	for name := range transformers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateTransformerName checks the transformer is registered
+func validateTransformerName(v interface{}, k string) ([]string, []error) {
+	name := v.(string)
+	if _, ok := responseTransformer(name); !ok {
+		return nil, []error{fmt.Errorf("%s: unknown transformer %q, expected one of %s", k, name, strings.Join(transformerNames(), ", "))}
+	}
+	return nil, nil
+}
+
+// transformerStep is a transformer applied by transformResponse
+type transformerStep struct {
+	Name   string
+	Params map[string]string
+}
+
+// transformResponse applies the transformers in order, each one receives
+// the body returned by the previous one
+func transformResponse(ctx context.Context, rsp *Response, steps []transformerStep) error {
+	for _, step := range steps {
+		transformer, ok := responseTransformer(step.Name)
+		if !ok {
+			return newRequestError(errorCodeConfig, "unknown response transformer %s", step.Name)
+		}
+
+		body, err := transformer(ctx, rsp, step.Params)
+		if err != nil {
+			return newRequestError(errorCodeBodyDecode, "response transformer %s: %s", step.Name, err)
+		}
+		rsp.Body = body
+		rsp.BodySize = int64(len(body))
+	}
+	return nil
+}
+
+func init() {
+	// base64_decode decodes a base64 body, standard or url encoding with the
+	// encoding param set to url
+	RegisterResponseTransformer("base64_decode", func(ctx context.Context, rsp *Response, params map[string]string) ([]byte, error) {
+		encoding := base64.StdEncoding
+		if params["encoding"] == "url" {
+			encoding = base64.URLEncoding
+		}
+		return encoding.DecodeString(strings.TrimSpace(string(rsp.Body)))
+	})
+}
@@ -0,0 +1,62 @@
+package httpclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// size units accepted by parseSize, decimal and binary
+var sizeUnits = map[string]int64{
+	"":    1,
+	"B":   1,
+	"KB":  1000,
+	"MB":  1000 * 1000,
+	"GB":  1000 * 1000 * 1000,
+	"KIB": 1024,
+	"MIB": 1024 * 1024,
+	"GIB": 1024 * 1024 * 1024,
+}
+
+// parseSize parses a human friendly size such as `512KB`, `5MB` or `1GiB`,
+// a bare number is a size in bytes
+func parseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	end := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if end == -1 {
+		end = len(s)
+	}
+
+	number, err := strconv.ParseFloat(s[:end], 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	unit, ok := sizeUnits[strings.ToUpper(strings.TrimSpace(s[end:]))]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit, use B, KB, MB, GB, KiB, MiB or GiB", s)
+	}
+	return int64(number * float64(unit)), nil
+}
+
+func validateSize(v interface{}, k string) ([]string, []error) {
+	if s := v.(string); len(s) > 0 {
+		if _, err := parseSize(s); err != nil {
+			return nil, []error{fmt.Errorf("%s: %s", k, err)}
+		}
+	}
+	return nil, nil
+}
+
+func validateDuration(v interface{}, k string) ([]string, []error) {
+	if s := v.(string); len(s) > 0 {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, []error{fmt.Errorf("%s: invalid duration %q, use a value such as 30s or 2m", k, s)}
+		}
+		if d <= 0 {
+			return nil, []error{fmt.Errorf("%s: duration must be positive", k)}
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,248 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// xmlNode is an element of a parsed xml document, names are local names so
+// that paths do not depend on the namespace prefixes chosen by the server
+type xmlNode struct {
+	name     string
+	attrs    map[string]string
+	children []*xmlNode
+	text     strings.Builder
+}
+
+// parseXML returns the document node, whose only child is the root element
+func parseXML(body []byte) (*xmlNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	// the text is decoded as is whatever the declared charset
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) { return input, nil }
+
+	doc := &xmlNode{}
+	stack := []*xmlNode{doc}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		parent := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name.Local, attrs: make(map[string]string)}
+			for _, attr := range t.Attr {
+				node.attrs[attr.Name.Local] = attr.Value
+			}
+			parent.children = append(parent.children, node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			parent.text.Write(t)
+		}
+	}
+	if len(doc.children) == 0 {
+		return nil, fmt.Errorf("no root element")
+	}
+	return doc, nil
+}
+
+// value is the text of the element and of its descendants
+func (n *xmlNode) value() string {
+	var sb strings.Builder
+	var walk func(*xmlNode)
+	walk = func(node *xmlNode) {
+		sb.WriteString(node.text.String())
+		for _, child := range node.children {
+			walk(child)
+		}
+	}
+	walk(n)
+	return strings.TrimSpace(sb.String())
+}
+
+// descendants returns the elements below the node, in document order
+func (n *xmlNode) descendants() []*xmlNode {
+	var nodes []*xmlNode
+	for _, child := range n.children {
+		nodes = append(nodes, child)
+		nodes = append(nodes, child.descendants()...)
+	}
+	return nodes
+}
+
+// xpathStep is a location step of a path, such as //soap:Body or item[2]
+type xpathStep struct {
+	descendant bool
+	name       string
+	predicates []string
+}
+
+// xpathLookup evaluates a simple XPath expression against a parsed document.
+// Child (`/a/b`) and descendant (`//b`) steps, `*`, position (`[1]`) and
+// attribute (`[@id='x']`) predicates are supported, a path may end with
+// `@attr` or `text()`. Namespace prefixes are ignored. The first match is
+// returned, the bool reports whether the path exists.
+func xpathLookup(doc *xmlNode, path string) (string, bool, error) {
+	steps, last, err := parseXPath(path)
+	if err != nil {
+		return "", false, err
+	}
+
+	nodes := []*xmlNode{doc}
+	for _, step := range steps {
+		var matched []*xmlNode
+		for _, node := range nodes {
+			candidates := node.children
+			if step.descendant {
+				candidates = node.descendants()
+			}
+			var named []*xmlNode
+			for _, candidate := range candidates {
+				if step.name == "*" || candidate.name == step.name {
+					named = append(named, candidate)
+				}
+			}
+			for _, predicate := range step.predicates {
+				named = filterXPath(named, predicate)
+			}
+			matched = append(matched, named...)
+		}
+		nodes = matched
+	}
+
+	for _, node := range nodes {
+		switch {
+		case len(last) == 0:
+			return node.value(), true, nil
+		case last == "text()":
+			return strings.TrimSpace(node.text.String()), true, nil
+		default:
+			if value, ok := node.attrs[last[1:]]; ok {
+				return value, true, nil
+			}
+		}
+	}
+	return "", false, nil
+}
+
+// filterXPath applies a position or attribute predicate
+func filterXPath(nodes []*xmlNode, predicate string) []*xmlNode {
+	if position, err := strconv.Atoi(predicate); err == nil {
+		if position < 1 || position > len(nodes) {
+			return nil
+		}
+		return nodes[position-1 : position]
+	}
+
+	name, value, has_value := strings.Cut(predicate[1:], "=")
+	name = localXMLName(strings.TrimSpace(name))
+	value = strings.Trim(strings.TrimSpace(value), `'"`)
+	var filtered []*xmlNode
+	for _, node := range nodes {
+		if attr, ok := node.attrs[name]; ok && (!has_value || attr == value) {
+			filtered = append(filtered, node)
+		}
+	}
+	return filtered
+}
+
+// parseXPath splits an XPath expression into its location steps and the
+// final attribute or text() selector
+func parseXPath(path string) ([]xpathStep, string, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, "", fmt.Errorf("invalid xpath %q: must start with /", path)
+	}
+
+	var steps []xpathStep
+	last := ""
+	rest := path
+	for len(rest) > 0 {
+		if len(last) > 0 {
+			return nil, "", fmt.Errorf("invalid xpath %q: %s must be the last step", path, last)
+		}
+		step := xpathStep{}
+		if strings.HasPrefix(rest, "//") {
+			step.descendant = true
+			rest = rest[2:]
+		} else if strings.HasPrefix(rest, "/") {
+			rest = rest[1:]
+		} else {
+			return nil, "", fmt.Errorf("invalid xpath %q: missing /", path)
+		}
+
+		// the step ends at the next / outside of a predicate
+		end, depth, quote := len(rest), 0, byte(0)
+		for i := 0; i < len(rest) && end == len(rest); i++ {
+			switch c := rest[i]; {
+			case quote != 0:
+				if c == quote {
+					quote = 0
+				}
+			case c == '\'' || c == '"':
+				quote = c
+			case c == '[':
+				depth++
+			case c == ']':
+				depth--
+			case c == '/' && depth == 0:
+				end = i
+			}
+		}
+		text := rest[:end]
+		rest = rest[end:]
+
+		name := text
+		if i := strings.Index(text, "["); i != -1 {
+			name = text[:i]
+			for predicates := text[i:]; len(predicates) > 0; {
+				end_bracket := strings.Index(predicates, "]")
+				if !strings.HasPrefix(predicates, "[") || end_bracket == -1 {
+					return nil, "", fmt.Errorf("invalid xpath %q: malformed predicate in %s", path, text)
+				}
+				predicate := strings.TrimSpace(predicates[1:end_bracket])
+				if _, err := strconv.Atoi(predicate); err != nil && !strings.HasPrefix(predicate, "@") {
+					return nil, "", fmt.Errorf("invalid xpath %q: unsupported predicate [%s]", path, predicate)
+				}
+				step.predicates = append(step.predicates, predicate)
+				predicates = predicates[end_bracket+1:]
+			}
+		}
+
+		switch {
+		case len(name) == 0:
+			return nil, "", fmt.Errorf("invalid xpath %q: empty step", path)
+		case name == "text()" || strings.HasPrefix(name, "@"):
+			if step.descendant || len(step.predicates) > 0 {
+				return nil, "", fmt.Errorf("invalid xpath %q: unsupported step %s", path, text)
+			}
+			last = name
+			if strings.HasPrefix(name, "@") {
+				last = "@" + localXMLName(name[1:])
+			}
+		default:
+			step.name = localXMLName(name)
+			steps = append(steps, step)
+		}
+	}
+	if len(steps) == 0 {
+		return nil, "", fmt.Errorf("invalid xpath %q: no element step", path)
+	}
+	return steps, last, nil
+}
+
+// localXMLName removes the namespace prefix of a name
+func localXMLName(name string) string {
+	if i := strings.Index(name, ":"); i != -1 {
+		return name[i+1:]
+	}
+	return name
+}
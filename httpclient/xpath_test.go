@@ -0,0 +1,88 @@
+package httpclient
+
+import (
+	"testing"
+)
+
+const xpathTestDocument = `<?xml version="1.0" encoding="ISO-8859-1"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:m="urn:example:orders">
+  <soap:Header><m:RequestId>42</m:RequestId></soap:Header>
+  <soap:Body>
+    <m:GetOrderResponse>
+      <m:Order m:id="o-1" status="shipped"><m:Total currency="EUR">10.50</m:Total></m:Order>
+      <m:Order m:id="o-2" status="pending"><m:Total currency="USD">7</m:Total><m:Note>gift <b>wrap</b></m:Note></m:Order>
+    </m:GetOrderResponse>
+  </soap:Body>
+</soap:Envelope>`
+
+func TestXPathLookup(t *testing.T) {
+	doc, err := parseXML([]byte(xpathTestDocument))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		path  string
+		value string
+		found bool
+	}{
+		// namespace prefixes of the path and of the document are ignored
+		{path: "/Envelope/Header/RequestId", value: "42", found: true},
+		{path: "/soap:Envelope/soap:Header/m:RequestId", value: "42", found: true},
+		{path: "/x:Envelope/y:Header/z:RequestId", value: "42", found: true},
+		{path: "//Order/Total", value: "10.50", found: true},
+		{path: "//Order[2]/Total", value: "7", found: true},
+		{path: "//Order[@id='o-2']/Total/@currency", value: "USD", found: true},
+		{path: "//m:Order[@m:id='o-2']/@status", value: "pending", found: true},
+		{path: `//Order[@status="shipped"]/@id`, value: "o-1", found: true},
+		{path: "//Order[@status]/Total", value: "10.50", found: true},
+		{path: "/Envelope/Body/*/Order/Total", value: "10.50", found: true},
+		// the value includes the text of the descendants, text() does not
+		{path: "//Note", value: "gift wrap", found: true},
+		{path: "//Note/text()", value: "gift", found: true},
+		// missing nodes
+		{path: "/Envelope/Body/Fault", found: false},
+		{path: "//Order[3]", found: false},
+		{path: "//Order[0]", found: false},
+		{path: "//Order[@id='o-3']", found: false},
+		{path: "//Order/@missing", found: false},
+		{path: "/Body", found: false},
+	}
+	for _, tt := range tests {
+		value, found, err := xpathLookup(doc, tt.path)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tt.path, err)
+			continue
+		}
+		if found != tt.found || value != tt.value {
+			t.Errorf("%s is %q (found %t), expected %q (found %t)", tt.path, value, found, tt.value, tt.found)
+		}
+	}
+}
+
+func TestXPathLookupErrors(t *testing.T) {
+	doc, _ := parseXML([]byte("<a><b>1</b></a>"))
+	for _, path := range []string{
+		"a/b",
+		"/",
+		"/a//",
+		"/a/b[",
+		"/a/b[last()]",
+		"/a/@id/b",
+		"/a/text()/b",
+		"//@id",
+		"/@id",
+	} {
+		if _, _, err := xpathLookup(doc, path); err == nil {
+			t.Errorf("%q: expected an error", path)
+		}
+	}
+}
+
+func TestParseXMLErrors(t *testing.T) {
+	for _, body := range []string{"", "not xml", "<a><b></a>", `{"json":true}`} {
+		if _, err := parseXML([]byte(body)); err == nil {
+			t.Errorf("%q: expected an error", body)
+		}
+	}
+}